@@ -1,34 +1,618 @@
 CREATE DATABASE IF NOT EXISTS bank-api;
 
-CREATE TYPE jenis_transaksi AS ENUM ('tabung', 'tarik');
+-- Skrip ini juga dipakai untuk memperbarui database dari versi skema sebelumnya. Kolom yang
+-- ditambahkan ke tabel lama ditulis ulang sebagai ALTER ... IF NOT EXISTS setelah CREATE TABLE
+-- masing-masing, sehingga seluruh skrip aman dijalankan ulang.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'jenis_transaksi') THEN
+        CREATE TYPE jenis_transaksi AS ENUM ('tabung', 'tarik');
+    END IF;
+END;
+$$;
+ALTER TYPE jenis_transaksi ADD VALUE IF NOT EXISTS 'transfer_keluar';
+ALTER TYPE jenis_transaksi ADD VALUE IF NOT EXISTS 'transfer_masuk';
+ALTER TYPE jenis_transaksi ADD VALUE IF NOT EXISTS 'koreksi_debit';
+ALTER TYPE jenis_transaksi ADD VALUE IF NOT EXISTS 'koreksi_kredit';
+ALTER TYPE jenis_transaksi ADD VALUE IF NOT EXISTS 'bunga';
+ALTER TYPE jenis_transaksi ADD VALUE IF NOT EXISTS 'pajak';
+ALTER TYPE jenis_transaksi ADD VALUE IF NOT EXISTS 'biaya';
 
 CREATE TABLE IF NOT EXISTS nasabah (
     id SERIAL PRIMARY KEY,
     nama VARCHAR(255),
     nik VARCHAR(50) UNIQUE,
     no_hp VARCHAR(20) UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    pin_hash VARCHAR(255) NOT NULL,
+    pin_gagal INTEGER NOT NULL DEFAULT 0,
+    pin_terkunci_at TIMESTAMP,
+    tanggal_lahir DATE,
+    tempat_lahir VARCHAR(100),
+    alamat TEXT,
+    nama_ibu_kandung VARCHAR(255),
+    pekerjaan VARCHAR(100),
+    rentang_penghasilan VARCHAR(20),
+    email VARCHAR(255),
+    no_dokumen VARCHAR(100),
+    kyc_status VARCHAR(20) NOT NULL DEFAULT 'unverified' CHECK (kyc_status IN ('unverified', 'pending', 'verified', 'rejected')),
+    kyc_catatan TEXT,
+    kyc_diverifikasi_oleh VARCHAR(100),
+    kyc_diverifikasi_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Nasabah dari skema sebelum login dan PIN diisi hash kosong. Hash kosong tidak cocok dengan
+-- password atau PIN apa pun, jadi nasabah tersebut belum bisa login maupun bertransaksi
+-- sampai petugas mengisikan kredensialnya.
+ALTER TABLE nasabah ADD COLUMN IF NOT EXISTS password_hash VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE nasabah ADD COLUMN IF NOT EXISTS pin_hash VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE nasabah ALTER COLUMN password_hash DROP DEFAULT;
+ALTER TABLE nasabah ALTER COLUMN pin_hash DROP DEFAULT;
+ALTER TABLE nasabah
+    ADD COLUMN IF NOT EXISTS pin_gagal INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS pin_terkunci_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS tanggal_lahir DATE,
+    ADD COLUMN IF NOT EXISTS tempat_lahir VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS alamat TEXT,
+    ADD COLUMN IF NOT EXISTS nama_ibu_kandung VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS pekerjaan VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS rentang_penghasilan VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS email VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS no_dokumen VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS kyc_status VARCHAR(20) NOT NULL DEFAULT 'unverified' CHECK (kyc_status IN ('unverified', 'pending', 'verified', 'rejected')),
+    ADD COLUMN IF NOT EXISTS kyc_catatan TEXT,
+    ADD COLUMN IF NOT EXISTS kyc_diverifikasi_oleh VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS kyc_diverifikasi_at TIMESTAMP;
+
+-- Produk rekening beserta aturannya, bisa diubah tanpa deploy ulang
+CREATE TABLE IF NOT EXISTS produk (
+    kode VARCHAR(20) PRIMARY KEY,
+    nama VARCHAR(100) NOT NULL,
+    saldo_minimum DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    boleh_tarik BOOLEAN NOT NULL DEFAULT TRUE,
+    -- biaya per produk; tarik dikenai biaya setelah gratis_tarik_per_bulan penarikan dalam sebulan
+    biaya_admin_bulanan DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    biaya_tarik DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    gratis_tarik_per_bulan INTEGER NOT NULL DEFAULT 0,
+    biaya_transfer DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    -- yang dilakukan jika saldo tidak cukup untuk membayar biaya penuh
+    kebijakan_biaya VARCHAR(20) NOT NULL DEFAULT 'tunggakan' CHECK (kebijakan_biaya IN ('lewati', 'sebagian', 'tunggakan')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Produk yang sudah ada sebelum biaya dipasang tetap tanpa biaya sampai tarifnya diatur
+ALTER TABLE produk
+    ADD COLUMN IF NOT EXISTS biaya_admin_bulanan DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS biaya_tarik DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS gratis_tarik_per_bulan INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS biaya_transfer DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS kebijakan_biaya VARCHAR(20) NOT NULL DEFAULT 'tunggakan' CHECK (kebijakan_biaya IN ('lewati', 'sebagian', 'tunggakan'));
+
+INSERT INTO produk (kode, nama, saldo_minimum, boleh_tarik, biaya_admin_bulanan, biaya_tarik, gratis_tarik_per_bulan, biaya_transfer, kebijakan_biaya) VALUES
+    ('tabungan', 'Tabungan', 0, TRUE, 10000, 5000, 5, 2500, 'tunggakan'),
+    ('deposito', 'Deposito Berjangka', 0, FALSE, 0, 0, 0, 0, 'lewati')
+ON CONFLICT (kode) DO NOTHING;
+
 -- Membuat tabel rekening
 CREATE TABLE IF NOT EXISTS rekening (
     id SERIAL PRIMARY KEY,
     nasabah_id INTEGER NOT NULL,
     no_rekening VARCHAR(50) UNIQUE,
+    kode_produk VARCHAR(20) NOT NULL DEFAULT 'tabungan',
     saldo DECIMAL(15, 2) DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'aktif' CHECK (status IN ('aktif', 'dormant', 'dibekukan', 'ditutup')),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (nasabah_id) REFERENCES nasabah(id)
+    FOREIGN KEY (nasabah_id) REFERENCES nasabah(id),
+    FOREIGN KEY (kode_produk) REFERENCES produk(kode)
+);
+
+-- Rekening lama menjadi rekening tabungan yang aktif
+ALTER TABLE rekening
+    ADD COLUMN IF NOT EXISTS kode_produk VARCHAR(20) NOT NULL DEFAULT 'tabungan',
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'aktif' CHECK (status IN ('aktif', 'dormant', 'dibekukan', 'ditutup'));
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'rekening_kode_produk_fkey') THEN
+        ALTER TABLE rekening ADD CONSTRAINT rekening_kode_produk_fkey FOREIGN KEY (kode_produk) REFERENCES produk(kode);
+    END IF;
+END;
+$$;
+
+CREATE INDEX IF NOT EXISTS idx_rekening_nasabah_id ON rekening(nasabah_id);
+
+-- Suku bunga tahunan per produk dalam basis poin (100 = 1%). Satu produk bisa punya beberapa
+-- tier: saldo harian memakai tarif tier dengan saldo_minimum tertinggi yang tidak melebihi
+-- saldo tersebut, untuk seluruh saldo. Perubahan tarif dicatat sebagai baris baru dengan
+-- berlaku_mulai yang baru sehingga akru tanggal lama tetap memakai tarif lamanya.
+CREATE TABLE IF NOT EXISTS suku_bunga (
+    id SERIAL PRIMARY KEY,
+    kode_produk VARCHAR(20) NOT NULL,
+    saldo_minimum DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    bunga_tahunan_bps INTEGER NOT NULL CHECK (bunga_tahunan_bps >= 0),
+    berlaku_mulai DATE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (kode_produk, berlaku_mulai, saldo_minimum),
+    FOREIGN KEY (kode_produk) REFERENCES produk(kode)
+);
+
+INSERT INTO suku_bunga (kode_produk, saldo_minimum, bunga_tahunan_bps, berlaku_mulai) VALUES
+    ('tabungan', 0, 0, '2020-01-01'),
+    ('tabungan', 1000000, 50, '2020-01-01'),
+    ('tabungan', 10000000, 100, '2020-01-01'),
+    ('tabungan', 100000000, 175, '2020-01-01'),
+    ('deposito', 0, 450, '2020-01-01')
+ON CONFLICT (kode_produk, berlaku_mulai, saldo_minimum) DO NOTHING;
+
+-- Batas transaksi per produk dan/atau tier KYC. Kolom kode_produk atau kyc_status yang NULL
+-- berarti berlaku untuk semua. Semua aturan yang cocok diterapkan sekaligus, kolom batas
+-- yang NULL berarti tidak dibatasi. Aturan tanpa kode_produk untuk satu kyc_status adalah
+-- plafon tier KYC: aturan per produk hanya bisa memperketatnya, tidak melonggarkannya.
+CREATE TABLE IF NOT EXISTS limit_transaksi (
+    id SERIAL PRIMARY KEY,
+    kode_produk VARCHAR(20),
+    kyc_status VARCHAR(20),
+    arah VARCHAR(10) NOT NULL CHECK (arah IN ('debit', 'kredit')),
+    maks_per_transaksi DECIMAL(15, 2),
+    maks_harian DECIMAL(15, 2),
+    maks_jumlah_harian INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (kode_produk) REFERENCES produk(kode)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_limit_transaksi_aturan
+    ON limit_transaksi (COALESCE(kode_produk, ''), COALESCE(kyc_status, ''), arah);
+
+INSERT INTO limit_transaksi (kode_produk, kyc_status, arah, maks_per_transaksi, maks_harian, maks_jumlah_harian) VALUES
+    (NULL, 'unverified', 'debit', 5000000, 10000000, 10),
+    (NULL, 'pending', 'debit', 5000000, 10000000, 10),
+    (NULL, 'rejected', 'debit', 5000000, 10000000, 10),
+    (NULL, 'verified', 'debit', 100000000, 200000000, 50),
+    (NULL, 'unverified', 'kredit', 10000000, 20000000, 20),
+    (NULL, 'pending', 'kredit', 10000000, 20000000, 20),
+    (NULL, 'rejected', 'kredit', 10000000, 20000000, 20),
+    (NULL, 'verified', 'kredit', 500000000, NULL, NULL)
+ON CONFLICT DO NOTHING;
+
+-- Buku besar double-entry. Setiap rekening nasabah punya satu akun_buku (kewajiban bank),
+-- ditambah akun internal seperti kas di khazanah dan suspense. Saldo rekening adalah
+-- SUM(kredit) - SUM(debit) dari posting akun rekening tersebut.
+CREATE TABLE IF NOT EXISTS akun_buku (
+    id SERIAL PRIMARY KEY,
+    kode VARCHAR(50) NOT NULL UNIQUE,
+    nama VARCHAR(255) NOT NULL,
+    jenis VARCHAR(20) NOT NULL CHECK (jenis IN ('aset', 'kewajiban', 'pendapatan', 'beban')),
+    rekening_id INTEGER UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (rekening_id) REFERENCES rekening(id)
 );
 
+INSERT INTO akun_buku (kode, nama, jenis) VALUES
+    ('KAS', 'Kas di Khazanah', 'aset'),
+    ('SUSPENSE', 'Rekening Perantara (Suspense)', 'kewajiban'),
+    ('BEBAN_BUNGA', 'Beban Bunga Tabungan', 'beban'),
+    ('UTANG_PAJAK', 'Utang Pajak Bunga (PPh Final)', 'kewajiban'),
+    ('PENDAPATAN_BIAYA', 'Pendapatan Biaya Administrasi', 'pendapatan')
+ON CONFLICT (kode) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS jurnal (
+    id SERIAL PRIMARY KEY,
+    no_referensi VARCHAR(50),
+    keterangan TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS posting (
+    id SERIAL PRIMARY KEY,
+    jurnal_id INTEGER NOT NULL,
+    akun_buku_id INTEGER NOT NULL,
+    debit DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    kredit DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    -- setiap posting hanya berisi salah satu sisi dengan nilai positif
+    CHECK (debit >= 0 AND kredit >= 0 AND (debit = 0) <> (kredit = 0)),
+    FOREIGN KEY (jurnal_id) REFERENCES jurnal(id),
+    FOREIGN KEY (akun_buku_id) REFERENCES akun_buku(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_posting_akun_buku_id ON posting(akun_buku_id);
+CREATE INDEX IF NOT EXISTS idx_posting_jurnal_id ON posting(jurnal_id);
+
+-- Jurnal harus seimbang saat commit, diperiksa sekali per jurnal setelah semua posting masuk
+CREATE OR REPLACE FUNCTION posting_jurnal_seimbang() RETURNS TRIGGER AS $$
+DECLARE
+    selisih DECIMAL(15, 2);
+BEGIN
+    SELECT COALESCE(SUM(debit - kredit), 0) INTO selisih FROM posting WHERE jurnal_id = NEW.jurnal_id;
+    IF selisih <> 0 THEN
+        RAISE EXCEPTION 'jurnal % tidak seimbang, selisih %', NEW.jurnal_id, selisih;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- CONSTRAINT TRIGGER tidak mendukung OR REPLACE, jadi dicek dulu agar skrip bisa dijalankan ulang
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'posting_jurnal_seimbang' AND tgrelid = 'posting'::regclass) THEN
+        CREATE CONSTRAINT TRIGGER posting_jurnal_seimbang
+            AFTER INSERT ON posting
+            DEFERRABLE INITIALLY DEFERRED
+            FOR EACH ROW EXECUTE FUNCTION posting_jurnal_seimbang();
+    END IF;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION buku_besar_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION '% hanya boleh ditambah, koreksi dilakukan dengan jurnal balik', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER jurnal_append_only
+    BEFORE UPDATE OR DELETE ON jurnal
+    FOR EACH ROW EXECUTE FUNCTION buku_besar_append_only();
+
+CREATE OR REPLACE TRIGGER posting_append_only
+    BEFORE UPDATE OR DELETE ON posting
+    FOR EACH ROW EXECUTE FUNCTION buku_besar_append_only();
+
+-- Rekening yang sudah ada sebelum buku besar dipasang belum punya akun buku. Buat akunnya,
+-- lalu bukukan saldonya saat ini sebagai jurnal saldo awal: saldo positif berasal dari setoran
+-- tunai (KAS), saldo negatif ditampung di SUSPENSE untuk ditelusuri. Aman dijalankan ulang
+-- karena hanya menyentuh rekening tanpa akun dan akun yang belum punya posting.
+INSERT INTO akun_buku (kode, nama, jenis, rekening_id)
+SELECT 'REK-' || r.no_rekening, 'Rekening ' || r.no_rekening, 'kewajiban', r.id
+FROM rekening r
+WHERE NOT EXISTS (SELECT 1 FROM akun_buku a WHERE a.rekening_id = r.id);
+
+DO $$
+DECLARE
+    akun RECORD;
+    jurnal_baru INTEGER;
+    akun_kas INTEGER := (SELECT id FROM akun_buku WHERE kode = 'KAS');
+    akun_suspense INTEGER := (SELECT id FROM akun_buku WHERE kode = 'SUSPENSE');
+BEGIN
+    FOR akun IN
+        SELECT a.id, r.no_rekening, r.saldo
+        FROM akun_buku a
+        JOIN rekening r ON r.id = a.rekening_id
+        WHERE COALESCE(r.saldo, 0) <> 0
+          AND NOT EXISTS (SELECT 1 FROM posting p WHERE p.akun_buku_id = a.id)
+    LOOP
+        INSERT INTO jurnal (keterangan)
+        VALUES ('saldo awal rekening ' || akun.no_rekening)
+        RETURNING id INTO jurnal_baru;
+
+        IF akun.saldo > 0 THEN
+            INSERT INTO posting (jurnal_id, akun_buku_id, debit, kredit) VALUES
+                (jurnal_baru, akun_kas, akun.saldo, 0),
+                (jurnal_baru, akun.id, 0, akun.saldo);
+        ELSE
+            INSERT INTO posting (jurnal_id, akun_buku_id, debit, kredit) VALUES
+                (jurnal_baru, akun.id, -akun.saldo, 0),
+                (jurnal_baru, akun_suspense, 0, -akun.saldo);
+        END IF;
+    END LOOP;
+END;
+$$;
+
 -- Membuat tabel transaksi
 CREATE TABLE IF NOT EXISTS transaksi (
     id SERIAL PRIMARY KEY,
     rekening_id INTEGER NOT NULL,
     nominal DECIMAL(15, 2),
     jenis_transaksi jenis_transaksi NOT NULL,
+    saldo_awal DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    saldo_akhir DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    -- dua baris transfer (keluar & masuk) berbagi no_referensi yang sama
+    no_referensi VARCHAR(50),
+    jurnal_id INTEGER,
+    -- diisi pada transaksi koreksi: transaksi yang dibatalkan, kode alasan dan petugasnya
+    transaksi_asal_id INTEGER,
+    kode_alasan VARCHAR(30),
+    operator VARCHAR(100),
+    -- diisi pada transaksi biaya: transaksi yang memicu biaya dan tagihan yang dibayar.
+    -- Transaksi pajak bunga menunjuk ke transaksi bunganya lewat transaksi_pemicu_id.
+    transaksi_pemicu_id INTEGER,
+    tagihan_biaya_id INTEGER,
+    -- diisi pada baris utama transaksi yang dikirim dengan Idempotency-Key
+    idempotency_key_id INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (rekening_id) REFERENCES rekening(id),
+    FOREIGN KEY (transaksi_asal_id) REFERENCES transaksi(id),
+    FOREIGN KEY (transaksi_pemicu_id) REFERENCES transaksi(id),
+    FOREIGN KEY (jurnal_id) REFERENCES jurnal(id)
+);
+
+-- Transaksi lama belum mencatat saldo sebelum dan sesudahnya. Saldo dihitung ulang dari
+-- urutan transaksi tiap rekening, hanya saat kolomnya baru ditambahkan.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'transaksi' AND column_name = 'saldo_akhir') THEN
+        ALTER TABLE transaksi
+            ADD COLUMN saldo_awal DECIMAL(15, 2) NOT NULL DEFAULT 0,
+            ADD COLUMN saldo_akhir DECIMAL(15, 2) NOT NULL DEFAULT 0;
+
+        UPDATE transaksi t
+        SET saldo_awal = m.saldo_akhir - m.mutasi, saldo_akhir = m.saldo_akhir
+        FROM (
+            SELECT id, mutasi, SUM(mutasi) OVER (PARTITION BY rekening_id ORDER BY created_at, id) AS saldo_akhir
+            FROM (
+                SELECT id, rekening_id, created_at,
+                    CASE WHEN jenis_transaksi::text IN ('tarik', 'transfer_keluar', 'koreksi_debit', 'pajak', 'biaya')
+                        THEN -COALESCE(nominal, 0) ELSE COALESCE(nominal, 0) END AS mutasi
+                FROM transaksi
+            ) x
+        ) m
+        WHERE t.id = m.id;
+    END IF;
+END;
+$$;
+
+ALTER TABLE transaksi
+    ADD COLUMN IF NOT EXISTS no_referensi VARCHAR(50),
+    ADD COLUMN IF NOT EXISTS jurnal_id INTEGER,
+    ADD COLUMN IF NOT EXISTS transaksi_asal_id INTEGER,
+    ADD COLUMN IF NOT EXISTS kode_alasan VARCHAR(30),
+    ADD COLUMN IF NOT EXISTS operator VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS transaksi_pemicu_id INTEGER,
+    ADD COLUMN IF NOT EXISTS tagihan_biaya_id INTEGER,
+    ADD COLUMN IF NOT EXISTS idempotency_key_id INTEGER;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'transaksi_jurnal_id_fkey') THEN
+        ALTER TABLE transaksi ADD CONSTRAINT transaksi_jurnal_id_fkey FOREIGN KEY (jurnal_id) REFERENCES jurnal(id);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'transaksi_transaksi_asal_id_fkey') THEN
+        ALTER TABLE transaksi ADD CONSTRAINT transaksi_transaksi_asal_id_fkey FOREIGN KEY (transaksi_asal_id) REFERENCES transaksi(id);
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'transaksi_transaksi_pemicu_id_fkey') THEN
+        ALTER TABLE transaksi ADD CONSTRAINT transaksi_transaksi_pemicu_id_fkey FOREIGN KEY (transaksi_pemicu_id) REFERENCES transaksi(id);
+    END IF;
+END;
+$$;
+
+CREATE INDEX IF NOT EXISTS idx_transaksi_no_referensi ON transaksi(no_referensi);
+-- setiap sisi transaksi dengan no_referensi yang sama hanya boleh ada sekali, sehingga dua
+-- transfer yang kebetulan mendapat nomor yang sama ditolak alih-alih tergabung. Baris koreksi
+-- sudah unik lewat transaksi_asal_id.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transaksi_no_referensi_sisi ON transaksi(no_referensi, jenis_transaksi) WHERE no_referensi IS NOT NULL AND transaksi_asal_id IS NULL;
+CREATE INDEX IF NOT EXISTS idx_transaksi_rekening_created_at ON transaksi(rekening_id, created_at);
+-- satu transaksi hanya bisa dikoreksi sekali
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transaksi_asal_id ON transaksi(transaksi_asal_id);
+-- satu Idempotency-Key hanya bisa menghasilkan satu transaksi, walaupun retry berjalan ulang
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transaksi_idempotency_key_id ON transaksi(idempotency_key_id);
+
+-- Menyimpan Idempotency-Key beserta hash request dan response pertama
+CREATE TABLE IF NOT EXISTS idempotency_key (
+    id SERIAL PRIMARY KEY,
+    kunci VARCHAR(255) NOT NULL,
+    endpoint VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    response_body TEXT,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (kunci, endpoint)
+);
+
+-- Log audit append-only (perubahan saldo, nasabah baru, aksi admin, kejadian PIN).
+-- Setiap baris menyimpan hash baris sebelumnya di rantai yang sama sehingga perubahan atau
+-- penghapusan baris lama memutus rantai dan terdeteksi oleh perintah audit-verify. Rantai
+-- dipisah per rekening (perubahan saldo) dan per nasabah (kejadian lain) agar penulisan audit
+-- hanya antre dengan transaksi lain pada rekening atau nasabah yang sama.
+--
+-- audit_event dari skema sebelum rantai hash tidak bisa dirantai tanpa mengubah isinya, jadi
+-- tabel lama disimpan sebagai audit_event_sebelum_rantai dan rantai baru dimulai dari kosong.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.tables WHERE table_name = 'audit_event')
+        AND NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'audit_event' AND column_name = 'hash') THEN
+        ALTER TABLE audit_event RENAME TO audit_event_sebelum_rantai;
+    END IF;
+END;
+$$;
+
+CREATE TABLE IF NOT EXISTS audit_event (
+    id SERIAL PRIMARY KEY,
+    rantai VARCHAR(50) NOT NULL,
+    nasabah_id INTEGER,
+    jenis VARCHAR(50) NOT NULL,
+    keterangan TEXT,
+    -- TIMESTAMPTZ agar waktu yang dibaca ulang sama persis dengan yang di-hash,
+    -- tidak bergantung pada TimeZone sesi database
+    created_at TIMESTAMPTZ NOT NULL,
+    prev_hash CHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL UNIQUE,
+    -- satu entri hanya punya satu penerus di rantainya
+    UNIQUE (rantai, prev_hash),
+    FOREIGN KEY (nasabah_id) REFERENCES nasabah(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_event_rantai ON audit_event(rantai, id);
+
+CREATE OR REPLACE FUNCTION audit_event_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_event hanya boleh ditambah, tidak boleh diubah atau dihapus';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER audit_event_append_only
+    BEFORE UPDATE OR DELETE ON audit_event
+    FOR EACH ROW EXECUTE FUNCTION audit_event_append_only();
+
+CREATE TABLE IF NOT EXISTS nasabah_riwayat (
+    id SERIAL PRIMARY KEY,
+    nasabah_id INTEGER NOT NULL,
+    field VARCHAR(50) NOT NULL,
+    nilai_lama TEXT,
+    nilai_baru TEXT,
+    diubah_oleh VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (nasabah_id) REFERENCES nasabah(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_nasabah_kyc_status ON nasabah (kyc_status);
+
+CREATE INDEX IF NOT EXISTS idx_nasabah_riwayat_nasabah_id ON nasabah_riwayat (nasabah_id, created_at);
+
+-- Hasil job rekonsiliasi: saldo rekening dibandingkan dengan saldo hasil hitung ulang
+-- dari transaksi dan dari posting buku besar
+CREATE TABLE IF NOT EXISTS rekonsiliasi (
+    id SERIAL PRIMARY KEY,
+    pemicu VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'berjalan' CHECK (status IN ('berjalan', 'selesai', 'gagal')),
+    -- alasan rekonsiliasi berhenti sebelum semua rekening diperiksa
+    pesan_gagal TEXT,
+    bekukan BOOLEAN NOT NULL DEFAULT FALSE,
+    jumlah_rekening INTEGER NOT NULL DEFAULT 0,
+    jumlah_selisih INTEGER NOT NULL DEFAULT 0,
+    mulai_at TIMESTAMP NOT NULL,
+    selesai_at TIMESTAMP
+);
+
+-- Rekonsiliasi lama yang sudah punya selesai_at dianggap selesai, sisanya berhenti di tengah jalan
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'rekonsiliasi' AND column_name = 'status') THEN
+        ALTER TABLE rekonsiliasi
+            ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'berjalan' CHECK (status IN ('berjalan', 'selesai', 'gagal')),
+            ADD COLUMN pesan_gagal TEXT;
+        UPDATE rekonsiliasi SET status = CASE WHEN selesai_at IS NOT NULL THEN 'selesai' ELSE 'gagal' END;
+    END IF;
+END;
+$$;
+
+CREATE TABLE IF NOT EXISTS rekonsiliasi_selisih (
+    id SERIAL PRIMARY KEY,
+    rekonsiliasi_id INTEGER NOT NULL,
+    rekening_id INTEGER NOT NULL,
+    no_rekening VARCHAR(50) NOT NULL,
+    saldo_rekening DECIMAL(15, 2) NOT NULL,
+    saldo_transaksi DECIMAL(15, 2) NOT NULL,
+    saldo_buku_besar DECIMAL(15, 2) NOT NULL,
+    dibekukan BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (rekonsiliasi_id) REFERENCES rekonsiliasi(id),
+    FOREIGN KEY (rekening_id) REFERENCES rekening(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rekonsiliasi_selisih_rekonsiliasi_id ON rekonsiliasi_selisih(rekonsiliasi_id);
+
+-- Tutup hari: satu baris per tanggal buku. Begitu baris tanggal dibuat, transaksi baru
+-- tidak boleh lagi tercatat pada tanggal tersebut
+CREATE TABLE IF NOT EXISTS tutup_hari (
+    tanggal DATE PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'berjalan' CHECK (status IN ('berjalan', 'selesai')),
+    pemicu VARCHAR(20) NOT NULL,
+    jumlah_rekening INTEGER NOT NULL DEFAULT 0,
+    mulai_at TIMESTAMP NOT NULL,
+    selesai_at TIMESTAMP
+);
+
+-- Saldo penutupan setiap rekening per tanggal buku, dibaca laporan, bunga dan rekening koran
+-- tanpa harus memindai tabel transaksi
+CREATE TABLE IF NOT EXISTS saldo_harian (
+    tanggal DATE NOT NULL,
+    rekening_id INTEGER NOT NULL,
+    no_rekening VARCHAR(50) NOT NULL,
+    kode_produk VARCHAR(20) NOT NULL,
+    saldo_akhir DECIMAL(15, 2) NOT NULL,
+    total_debit DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    total_kredit DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    jumlah_transaksi INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tanggal, rekening_id),
+    FOREIGN KEY (tanggal) REFERENCES tutup_hari(tanggal),
     FOREIGN KEY (rekening_id) REFERENCES rekening(id)
 );
+
+CREATE INDEX IF NOT EXISTS idx_saldo_harian_rekening_id ON saldo_harian(rekening_id, tanggal);
+
+-- Total transaksi seluruh rekening per jenis pada satu tanggal buku
+CREATE TABLE IF NOT EXISTS tutup_hari_total (
+    tanggal DATE NOT NULL,
+    jenis_transaksi jenis_transaksi NOT NULL,
+    jumlah_transaksi INTEGER NOT NULL DEFAULT 0,
+    total_nominal DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    PRIMARY KEY (tanggal, jenis_transaksi),
+    FOREIGN KEY (tanggal) REFERENCES tutup_hari(tanggal)
+);
+
+CREATE OR REPLACE FUNCTION transaksi_tanggal_buku_terbuka() RETURNS TRIGGER AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM tutup_hari WHERE tanggal = COALESCE(NEW.created_at, LOCALTIMESTAMP)::date) THEN
+        RAISE EXCEPTION 'tanggal buku % sudah ditutup', COALESCE(NEW.created_at, LOCALTIMESTAMP)::date;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER transaksi_tanggal_buku_terbuka
+    BEFORE INSERT ON transaksi
+    FOR EACH ROW EXECUTE FUNCTION transaksi_tanggal_buku_terbuka();
+
+-- Bunga harian per rekening dari saldo penutupan, dalam mikrosen (1/1.000.000 sen) agar
+-- pecahan sen tidak hilang sebelum dijumlahkan dan dikreditkan di akhir bulan
+CREATE TABLE IF NOT EXISTS bunga_harian (
+    tanggal DATE NOT NULL,
+    rekening_id INTEGER NOT NULL,
+    saldo DECIMAL(15, 2) NOT NULL,
+    bunga_tahunan_bps INTEGER NOT NULL,
+    bunga_mikrosen BIGINT NOT NULL,
+    dikreditkan BOOLEAN NOT NULL DEFAULT FALSE,
+    transaksi_id INTEGER,
+    PRIMARY KEY (tanggal, rekening_id),
+    FOREIGN KEY (tanggal, rekening_id) REFERENCES saldo_harian(tanggal, rekening_id),
+    FOREIGN KEY (transaksi_id) REFERENCES transaksi(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bunga_harian_belum_dikreditkan ON bunga_harian(rekening_id, tanggal) WHERE NOT dikreditkan;
+
+-- Tanggal buku yang bunga hariannya sudah selesai diakru
+CREATE TABLE IF NOT EXISTS akru_bunga (
+    tanggal DATE PRIMARY KEY,
+    jumlah_rekening INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (tanggal) REFERENCES tutup_hari(tanggal)
+);
+
+-- Setiap biaya yang dikenakan ke rekening. Biaya yang tidak tertagih penuh dengan kebijakan
+-- tunggakan tetap berstatus tunggakan sampai sisanya tertagih pada penagihan berikutnya
+CREATE TABLE IF NOT EXISTS tagihan_biaya (
+    id SERIAL PRIMARY KEY,
+    rekening_id INTEGER NOT NULL,
+    jenis_biaya VARCHAR(20) NOT NULL CHECK (jenis_biaya IN ('admin_bulanan', 'tarik', 'transfer')),
+    -- awal bulan untuk biaya admin bulanan
+    periode DATE,
+    transaksi_pemicu_id INTEGER,
+    nominal DECIMAL(15, 2) NOT NULL,
+    tertagih DECIMAL(15, 2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('lunas', 'sebagian', 'dilewati', 'tunggakan', 'dibatalkan')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (rekening_id) REFERENCES rekening(id),
+    FOREIGN KEY (transaksi_pemicu_id) REFERENCES transaksi(id)
+);
+
+-- biaya admin hanya dikenakan sekali per rekening per bulan
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tagihan_biaya_admin_bulanan ON tagihan_biaya(rekening_id, periode) WHERE jenis_biaya = 'admin_bulanan';
+CREATE INDEX IF NOT EXISTS idx_tagihan_biaya_tunggakan ON tagihan_biaya(rekening_id, id) WHERE status = 'tunggakan';
+
+-- tagihan_biaya dibuat setelah transaksi, jadi FK-nya ditambahkan di sini bila belum ada
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_transaksi_tagihan_biaya' AND conrelid = 'transaksi'::regclass) THEN
+        ALTER TABLE transaksi ADD CONSTRAINT fk_transaksi_tagihan_biaya FOREIGN KEY (tagihan_biaya_id) REFERENCES tagihan_biaya(id);
+    END IF;
+END;
+$$;
+CREATE INDEX IF NOT EXISTS idx_transaksi_pemicu_id ON transaksi(transaksi_pemicu_id);
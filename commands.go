@@ -0,0 +1,187 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/sferawann/go-bank-api/config"
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+	"github.com/sferawann/go-bank-api/usecase"
+	"github.com/sferawann/go-bank-api/utils"
+)
+
+// runCommand menjalankan subcommand CLI dan mengembalikan exit code
+func runCommand(args []string) int {
+	switch args[0] {
+	case "audit-verify":
+		return auditVerify()
+	case "rekonsiliasi":
+		return rekonsiliasi(args[1:])
+	case "tutup-hari":
+		return tutupHari(args[1:])
+	case "bunga":
+		return bunga()
+	case "biaya-admin":
+		return biayaAdmin(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "perintah tidak dikenal: %s\n", args[0])
+		fmt.Fprintln(os.Stderr, "perintah yang tersedia: audit-verify, rekonsiliasi [--bekukan], tutup-hari [--tanggal YYYY-MM-DD], bunga, biaya-admin [--periode YYYY-MM]")
+		return 2
+	}
+}
+
+// auditVerify memeriksa rantai hash audit_event, exit code 1 jika ditemukan kerusakan
+func auditVerify() int {
+	config.InitDB()
+
+	hasil, err := usecase.VerifyAuditChain(repository.NewAuditRepository(config.DB))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gagal memverifikasi rantai audit:", err)
+		return 2
+	}
+
+	for _, temuan := range hasil.Temuan {
+		fmt.Println(temuan)
+	}
+	if !hasil.Valid {
+		fmt.Printf("rantai audit RUSAK: %d temuan dari %d entri\n", len(hasil.Temuan), hasil.JumlahEntri)
+		return 1
+	}
+	fmt.Printf("rantai audit utuh: %d entri diperiksa\n", hasil.JumlahEntri)
+	return 0
+}
+
+// rekonsiliasi mencocokkan saldo semua rekening, exit code 1 jika ditemukan selisih
+func rekonsiliasi(args []string) int {
+	flags := flag.NewFlagSet("rekonsiliasi", flag.ContinueOnError)
+	bekukan := flags.Bool("bekukan", false, "bekukan rekening yang saldonya selisih")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	config.InitDB()
+	db := config.DB
+
+	rekonsiliasiUsecase := usecase.NewRekonsiliasiUsecase(repository.NewRekeningRepository(db), repository.NewRekonsiliasiRepository(db), repository.NewUnitOfWork(db), utils.NewSystemClock())
+	run, err := rekonsiliasiUsecase.Jalankan(model.PemicuCLI, *bekukan)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gagal menjalankan rekonsiliasi:", err)
+		return 2
+	}
+
+	if run.JumlahSelisih > 0 {
+		fmt.Printf("rekonsiliasi #%d: %d dari %d rekening SELISIH, lihat GET /go-bank-api/admin/rekonsiliasi/%d\n", run.ID, run.JumlahSelisih, run.JumlahRekening, run.ID)
+		return 1
+	}
+	fmt.Printf("rekonsiliasi #%d: %d rekening cocok\n", run.ID, run.JumlahRekening)
+	return 0
+}
+
+// tutupHari menutup satu tanggal buku. Tanpa --tanggal, semua tanggal yang belum ditutup
+// sampai kemarin ditutup berurutan.
+func tutupHari(args []string) int {
+	flags := flag.NewFlagSet("tutup-hari", flag.ContinueOnError)
+	tanggalFlag := flags.String("tanggal", "", "tanggal buku yang ditutup, format YYYY-MM-DD")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var tanggal []time.Time
+	if *tanggalFlag != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", *tanggalFlag, time.Local)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "tanggal harus berformat YYYY-MM-DD")
+			return 2
+		}
+		tanggal = append(tanggal, parsed)
+	}
+
+	config.InitDB()
+	db := config.DB
+
+	clock := utils.NewSystemClock()
+	tutupHariUsecase := usecase.NewTutupHariUsecase(repository.NewRekeningRepository(db), repository.NewTransaksiRepository(db), repository.NewTutupHariRepository(db), repository.NewUnitOfWork(db), clock)
+	if len(tanggal) == 0 {
+		berikutnya, err := tutupHariUsecase.TanggalBerikutnya()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "gagal membaca tanggal buku berikutnya:", err)
+			return 2
+		}
+		now := clock.Now()
+		hariIni := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+		for t := berikutnya; t.Before(hariIni); t = t.AddDate(0, 0, 1) {
+			tanggal = append(tanggal, t)
+		}
+		if len(tanggal) == 0 {
+			fmt.Println("tidak ada tanggal buku yang perlu ditutup")
+			return 0
+		}
+	}
+
+	for _, t := range tanggal {
+		hasil, err := tutupHariUsecase.Jalankan(t, model.PemicuCLI)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "gagal menutup tanggal %s: %v\n", t.Format("2006-01-02"), err)
+			return 1
+		}
+		fmt.Printf("tanggal %s ditutup: %d rekening\n", t.Format("2006-01-02"), hasil.JumlahRekening)
+	}
+	return 0
+}
+
+// bunga mengakru bunga harian untuk tanggal buku yang sudah ditutup dan mengkreditkan
+// bunga bulan yang sudah berakhir. Dijalankan setelah tutup-hari.
+func bunga() int {
+	config.InitDB()
+	config.InitBunga()
+	db := config.DB
+
+	bungaUsecase := usecase.NewBungaUsecase(repository.NewTutupHariRepository(db), repository.NewBungaRepository(db), repository.NewUnitOfWork(db), utils.NewSystemClock(), config.PajakBungaPersen, config.PajakBungaBatasSaldo)
+	hasil, err := bungaUsecase.Proses()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gagal memproses bunga:", err)
+		return 1
+	}
+
+	fmt.Printf("bunga diakru untuk %d tanggal buku\n", len(hasil.TanggalDiakru))
+	for _, periode := range hasil.PeriodeDikreditkan {
+		fmt.Printf("bunga periode %s dikreditkan\n", periode)
+	}
+	fmt.Printf("%d rekening menerima bunga\n", hasil.RekeningDikreditkan)
+	return 0
+}
+
+// biayaAdmin menagih biaya admin bulanan semua rekening. Tanpa --periode, yang ditagih
+// adalah bulan lalu. Tunggakan biaya yang masih terbuka ikut ditagih ulang.
+func biayaAdmin(args []string) int {
+	flags := flag.NewFlagSet("biaya-admin", flag.ContinueOnError)
+	periodeFlag := flags.String("periode", "", "periode biaya admin, format YYYY-MM")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	config.InitDB()
+	db := config.DB
+
+	biayaUsecase := usecase.NewBiayaUsecase(repository.NewRekeningRepository(db), repository.NewBiayaRepository(db), repository.NewUnitOfWork(db), utils.NewSystemClock())
+	periode := biayaUsecase.PeriodeTerakhir()
+	if *periodeFlag != "" {
+		parsed, err := time.ParseInLocation("2006-01", *periodeFlag, time.Local)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "periode harus berformat YYYY-MM")
+			return 2
+		}
+		periode = parsed
+	}
+
+	hasil, err := biayaUsecase.TagihAdminBulanan(periode)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gagal menagih biaya admin:", err)
+		return 1
+	}
+	fmt.Printf("biaya admin periode %s: %d rekening dikenakan, total tertagih %s\n", hasil.Periode, hasil.JumlahRekening, hasil.TotalTertagih)
+	return 0
+}
@@ -0,0 +1,17 @@
+package config
+
+import (
+	"log"
+	"os"
+)
+
+var AdminAPIKey []byte
+
+// InitAdmin membaca kunci API untuk endpoint admin. Dipanggil setelah InitDB.
+func InitAdmin() {
+	key := os.Getenv("ADMIN_API_KEY")
+	if len(key) < 32 {
+		log.Fatal("ADMIN_API_KEY wajib diisi minimal 32 karakter")
+	}
+	AdminAPIKey = []byte(key)
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+var (
+	AuthSecret  []byte
+	TokenTTL    time.Duration
+	PinMaxGagal int
+)
+
+// InitAuth membaca konfigurasi token login. Dipanggil setelah InitDB agar file .env sudah dimuat.
+func InitAuth() {
+	secret := os.Getenv("AUTH_SECRET")
+	if len(secret) < 32 {
+		log.Fatal("AUTH_SECRET wajib diisi minimal 32 karakter")
+	}
+	AuthSecret = []byte(secret)
+
+	TokenTTL = time.Hour
+	if ttl := os.Getenv("TOKEN_TTL"); ttl != "" {
+		parsed, err := time.ParseDuration(ttl)
+		if err != nil {
+			log.Fatal("gagal memparse TOKEN_TTL: ", err)
+		}
+		TokenTTL = parsed
+	}
+
+	PinMaxGagal = 3
+	if maxGagal := os.Getenv("PIN_MAX_GAGAL"); maxGagal != "" {
+		parsed, err := strconv.Atoi(maxGagal)
+		if err != nil || parsed <= 0 {
+			log.Fatal("PIN_MAX_GAGAL harus bilangan bulat positif")
+		}
+		PinMaxGagal = parsed
+	}
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/sferawann/go-bank-api/model"
+)
+
+var (
+	PajakBungaPersen     int
+	PajakBungaBatasSaldo model.Money
+)
+
+// InitBunga membaca tarif pajak bunga. Bawaannya PPh final 20% untuk rekening dengan saldo
+// di atas Rp7.500.000. Dipanggil setelah InitDB.
+func InitBunga() {
+	PajakBungaPersen = 20
+	if persen := os.Getenv("PAJAK_BUNGA_PERSEN"); persen != "" {
+		parsed, err := strconv.Atoi(persen)
+		if err != nil || parsed < 0 || parsed > 100 {
+			log.Fatal("PAJAK_BUNGA_PERSEN harus bilangan bulat 0 sampai 100")
+		}
+		PajakBungaPersen = parsed
+	}
+
+	PajakBungaBatasSaldo = model.Rupiah(7500000)
+	if batas := os.Getenv("PAJAK_BUNGA_BATAS_SALDO"); batas != "" {
+		parsed, err := model.ParseMoney(batas)
+		if err != nil || parsed < 0 {
+			log.Fatal("PAJAK_BUNGA_BATAS_SALDO harus berupa nominal yang valid")
+		}
+		PajakBungaBatasSaldo = parsed
+	}
+}
@@ -0,0 +1,24 @@
+package config
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+var IdempotencyProsesTTL time.Duration
+
+// InitIdempotency membaca berapa lama Idempotency-Key boleh berstatus diproses tanpa
+// diperpanjang oleh request pemiliknya sebelum dianggap tertinggal dan boleh diambil alih
+// oleh retry. Retry yang mengambil alih tetap mendapat transaksi yang sudah ter-commit untuk
+// key tersebut, bukan transaksi baru. Dipanggil setelah InitDB.
+func InitIdempotency() {
+	IdempotencyProsesTTL = time.Minute
+	if ttl := os.Getenv("IDEMPOTENCY_PROSES_TTL"); ttl != "" {
+		parsed, err := time.ParseDuration(ttl)
+		if err != nil || parsed <= 0 {
+			log.Fatal("IDEMPOTENCY_PROSES_TTL harus durasi positif, misalnya 1m")
+		}
+		IdempotencyProsesTTL = parsed
+	}
+}
@@ -0,0 +1,13 @@
+package config
+
+import "os"
+
+var KodeCabang string
+
+// InitRekening membaca konfigurasi penomoran rekening. Dipanggil setelah InitDB.
+func InitRekening() {
+	KodeCabang = os.Getenv("NOREK_KODE_CABANG")
+	if KodeCabang == "" {
+		KodeCabang = "001"
+	}
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+var (
+	// RekonsiliasiInterval bernilai 0 jika rekonsiliasi terjadwal dimatikan
+	RekonsiliasiInterval time.Duration
+	RekonsiliasiBekukan  bool
+)
+
+// InitRekonsiliasi membaca jadwal rekonsiliasi saldo. Dipanggil setelah InitDB.
+func InitRekonsiliasi() {
+	if interval := os.Getenv("REKONSILIASI_INTERVAL"); interval != "" {
+		parsed, err := time.ParseDuration(interval)
+		if err != nil || parsed <= 0 {
+			log.Fatal("REKONSILIASI_INTERVAL harus berupa durasi positif, contoh: 24h")
+		}
+		RekonsiliasiInterval = parsed
+	}
+
+	if bekukan := os.Getenv("REKONSILIASI_BEKUKAN"); bekukan != "" {
+		parsed, err := strconv.ParseBool(bekukan)
+		if err != nil {
+			log.Fatal("REKONSILIASI_BEKUKAN harus berupa true atau false")
+		}
+		RekonsiliasiBekukan = parsed
+	}
+}
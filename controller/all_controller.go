@@ -3,8 +3,11 @@ package controller
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/sferawann/go-bank-api/middleware"
 	"github.com/sferawann/go-bank-api/model"
 	"github.com/sferawann/go-bank-api/usecase"
 	"github.com/sferawann/go-bank-api/utils"
@@ -16,10 +19,39 @@ type AllController interface {
 	Tabung(ctx echo.Context) error
 	Tarik(ctx echo.Context) error
 	GetSaldo(ctx echo.Context) error
+	Transfer(ctx echo.Context) error
+	Mutasi(ctx echo.Context) error
+	Login(ctx echo.Context) error
+	UbahPin(ctx echo.Context) error
+	ResetPin(ctx echo.Context) error
+	BukaRekening(ctx echo.Context) error
+	ListRekening(ctx echo.Context) error
+	UbahStatusRekening(ctx echo.Context) error
+	GetNasabah(ctx echo.Context) error
+	UbahProfil(ctx echo.Context) error
+	RiwayatNasabah(ctx echo.Context) error
+	CariNasabah(ctx echo.Context) error
+	AjukanKYC(ctx echo.Context) error
+	AntreanKYC(ctx echo.Context) error
+	SetujuiKYC(ctx echo.Context) error
+	TolakKYC(ctx echo.Context) error
+	Reversal(ctx echo.Context) error
+	JalankanRekonsiliasi(ctx echo.Context) error
+	ListRekonsiliasi(ctx echo.Context) error
+	GetRekonsiliasi(ctx echo.Context) error
+	TutupHari(ctx echo.Context) error
+	GetTutupHari(ctx echo.Context) error
+	ProsesBunga(ctx echo.Context) error
+	TagihBiayaAdmin(ctx echo.Context) error
+	ListTunggakan(ctx echo.Context) error
 }
 
 type allController struct {
-	AllUsecase usecase.AllUsecase
+	AllUsecase          usecase.AllUsecase
+	RekonsiliasiUsecase usecase.RekonsiliasiUsecase
+	TutupHariUsecase    usecase.TutupHariUsecase
+	BungaUsecase        usecase.BungaUsecase
+	BiayaUsecase        usecase.BiayaUsecase
 }
 
 func (c *allController) Create(ctx echo.Context) error {
@@ -30,18 +62,8 @@ func (c *allController) Create(ctx echo.Context) error {
 
 	var newNasabah model.Nasabah
 
-	if err := ctx.Bind(&newNasabah); err != nil {
-		utils.Log.WithError(err).WithFields(logrus.Fields{
-			"action": "bind data create nasabah",
-			"layer":  "allController",
-		}).Error("Format data req tidak valid")
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"remark": "Format Data Tidak Valid!"})
-	}
-
-	if newNasabah.NIK == "" || newNasabah.Nama == "" {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{
-			"remark": "Field nik dan nama wajib diisi",
-		})
+	if err := bind(ctx, &newNasabah); err != nil {
+		return err
 	}
 
 	utils.Log.WithFields(logrus.Fields{
@@ -53,32 +75,7 @@ func (c *allController) Create(ctx echo.Context) error {
 	}).Info("Meneruskan permintaan pembuatan nasabah ke usecase")
 	createdNasabah, err := c.AllUsecase.Create(newNasabah)
 	if err != nil {
-		// fmt.Println("aaaaERROR:", err.Error())
-		utils.Log.WithError(err).WithFields(logrus.Fields{
-			"nama":   newNasabah.Nama,
-			"nik":    newNasabah.NIK,
-			"no_hp":  newNasabah.NoHP,
-			"action": "create",
-			"layer":  "allController",
-		}).Error("Gagal membuat nasabah melalui usecase")
-		if err.Error() == "nik sudah digunakan" || err.Error() == "no hp sudah digunakan" {
-			utils.Log.WithFields(logrus.Fields{
-				"error":  err.Error(),
-				"action": "validasi",
-				"layer":  "allController",
-			}).Warn("validasi data gagal")
-			return ctx.JSON(http.StatusBadRequest, map[string]string{
-				"remark": err.Error(),
-			})
-		}
-
-		utils.Log.WithError(err).WithFields(logrus.Fields{
-			"action": "internal_server_error",
-			"layer":  "allController",
-		}).Error("Terjadi kesalahan pada server saat membuat nasabah")
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"remark": "Terjadi kesalahan pada server",
-		})
+		return err
 	}
 
 	utils.Log.WithFields(logrus.Fields{
@@ -88,15 +85,7 @@ func (c *allController) Create(ctx echo.Context) error {
 	}).Info("Mencari data rekening berdasarkan ID nasabah")
 	rekening, err := c.AllUsecase.FindByNasabahID(createdNasabah.ID)
 	if err != nil {
-		// fmt.Println("ERROR FIND NASABAH ID:", err.Error())
-		utils.Log.WithError(err).WithFields(logrus.Fields{
-			"id":     createdNasabah.ID,
-			"action": "FindByNasabahID",
-			"layer":  "allController",
-		}).Error("Gagal mengambil data rekening")
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"remark": "Gagal mengambil data rekening",
-		})
+		return err
 	}
 
 	utils.Log.WithFields(logrus.Fields{
@@ -116,87 +105,34 @@ func (c *allController) Tabung(ctx echo.Context) error {
 		"action": "bind data tabung",
 		"layer":  "allController",
 	}).Info("Mencoba memproses data req pembuatan transaksi tabung")
-	if err := ctx.Bind(&newTabung); err != nil {
-		utils.Log.WithError(err).WithFields(logrus.Fields{
-			"action": "bind data tabung",
-			"layer":  "allController",
-		}).Error("Format data req tidak valid")
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"remark": "Format Data Tidak Valid!"})
+	if err := bind(ctx, &newTabung); err != nil {
+		return err
 	}
+	newTabung.IdempotencyKeyID = middleware.IdempotencyKeyID(ctx)
 
 	utils.Log.WithFields(logrus.Fields{
 		"no_rekening": newTabung.Rekening.NoRekening,
 		"action":      "find rekening tabung",
 		"layer":       "allController",
 	}).Info("Mengecek apakah rekening tersedia")
-	_, err := c.AllUsecase.FindByNoREK(newTabung.Rekening.NoRekening)
-	if err != nil {
-		utils.Log.WithError(err).WithFields(logrus.Fields{
-			"no_rekening": newTabung.Rekening.NoRekening,
-			"action":      "find rekening tabung",
-			"layer":       "allController",
-		}).Warn("Rekening tidak ditemukan")
-		return ctx.JSON(http.StatusBadRequest, map[string]string{
-			"remark": "Rekening tidak ditemukan",
-		})
+	if _, err := c.authorizeRekening(ctx, newTabung.Rekening.NoRekening); err != nil {
+		return err
 	}
 
 	createdTabung, err := c.AllUsecase.Tabung(newTabung)
 	if err != nil {
-		// fmt.Println("TABUNG ERORR:", err.Error())
-		utils.Log.WithError(err).WithFields(logrus.Fields{
-			"no_rekening": newTabung.Rekening.NoRekening,
-			"nominal":     newTabung.Nominal,
-			"action":      "create transaksi tabung",
-			"layer":       "allController",
-		}).Error("Gagal melakukan transaksi tabung")
-		if err.Error() == "rekening tidak ditemukan" {
-			utils.Log.WithError(err).WithFields(logrus.Fields{
-				"no_rekening": newTabung.Rekening.NoRekening,
-				"action":      "validasi",
-				"layer":       "allController",
-			}).Error("rekening tidak ditemukan")
-			return ctx.JSON(http.StatusBadRequest, map[string]string{
-				"remark": err.Error(),
-			})
-		}
-		utils.Log.WithError(err).WithFields(logrus.Fields{
-			"action": "internal_server_error",
-			"layer":  "allController",
-		}).Error("Terjadi kesalahan pada server saat membuat transaksi tabung")
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"remark": "Terjadi kesalahan pada server",
-		})
+		return err
 	}
 
 	utils.Log.WithFields(logrus.Fields{
-		"no_rekening": createdTabung.RekeningID,
-		"action":      "FindByRekeningID",
-		"layer":       "allController",
-	}).Info("Mengecek apakah rekening tersedia berdasarkan rekeningg id")
-	rekening, err := c.AllUsecase.FindByRekeningID(createdTabung.RekeningID)
-	if err != nil {
-		// fmt.Println("ERROR FIND REKENING ID:", err.Error())
-		utils.Log.WithError(err).WithFields(logrus.Fields{
-			"rekening_id": createdTabung.RekeningID,
-			"action":      "FindByRekeningID",
-			"layer":       "allController",
-		}).Error("Gagal mengambil data rekening setelah tabung")
-		return ctx.JSON(http.StatusBadRequest, map[string]string{
-			"remark": "Gagal mengambil data rekening",
-		})
-	}
-
-	utils.Log.WithFields(logrus.Fields{
-		"no_rekening": rekening.Rekening.NoRekening,
-		"saldo":       rekening.Rekening.Saldo,
+		"no_rekening": newTabung.Rekening.NoRekening,
+		"saldo":       createdTabung.SaldoAkhir,
 		"action":      "create transaksi tabung",
 		"layer":       "allController",
 	}).Info("Berhasil melakukan transaksi tabung")
 	return ctx.JSON(http.StatusOK, map[string]interface{}{
-		"saldo": rekening.Rekening.Saldo,
+		"saldo": createdTabung.SaldoAkhir,
 	})
-
 }
 
 func (c *allController) Tarik(ctx echo.Context) error {
@@ -207,53 +143,31 @@ func (c *allController) Tarik(ctx echo.Context) error {
 		"layer":  "allController",
 	}).Info("Mencoba memproses data req pembuatan transaksi tarik")
 
-	if err := ctx.Bind(&newTarik); err != nil {
-		utils.Log.WithError(err).WithFields(logrus.Fields{
-			"action": "bind data tarik",
-			"layer":  "allController",
-		}).Error("Format data req tidak valid")
-		return ctx.JSON(http.StatusBadRequest, map[string]string{"remark": "Format Data Tidak Valid!"})
+	if err := bind(ctx, &newTarik); err != nil {
+		return err
 	}
+	newTarik.IdempotencyKeyID = middleware.IdempotencyKeyID(ctx)
 
-	_, err := c.AllUsecase.FindByNoREK(newTarik.Rekening.NoRekening)
-	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, map[string]string{
-			"remark": "Rekening tidak ditemukan",
-		})
+	if _, err := c.authorizeRekening(ctx, newTarik.Rekening.NoRekening); err != nil {
+		return err
 	}
 
 	createdTarik, err := c.AllUsecase.Tarik(newTarik)
 	if err != nil {
-		utils.Log.WithError(err).WithFields(logrus.Fields{
-			"no_rekening": newTarik.Rekening.NoRekening,
-			"action":      "tarik saldo",
-			"layer":       "allController",
-		}).Error("Gagal melakukan penarikan saldo")
-		// fmt.Println("TARIK ERORR:", err.Error())
-		if err.Error() == "rekening tidak ditemukan" || err.Error() == "saldo tidak mencukupi" {
-			return ctx.JSON(http.StatusBadRequest, map[string]string{
-				"remark": err.Error(),
-			})
-		}
-
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"remark": "Terjadi kesalahan pada server",
-		})
-	}
-	// return ctx.JSON(http.StatusOK, createdTabung)
-
-	rekening, err := c.AllUsecase.FindByRekeningID(createdTarik.RekeningID)
-	if err != nil {
-		fmt.Println("ERROR FIND REKENING ID:", err.Error())
-		return ctx.JSON(http.StatusInternalServerError, map[string]string{
-			"remark": "Gagal mengambil data rekening",
-		})
+		return err
 	}
 
+	utils.Log.WithFields(logrus.Fields{
+		"no_rekening": newTarik.Rekening.NoRekening,
+		"saldo":       createdTarik.Saldo,
+		"biaya":       createdTarik.Biaya,
+		"action":      "tarik saldo",
+		"layer":       "allController",
+	}).Info("Berhasil melakukan penarikan saldo")
 	return ctx.JSON(http.StatusOK, map[string]interface{}{
-		"saldo": rekening.Rekening.Saldo,
+		"saldo": createdTarik.Saldo,
+		"biaya": createdTarik.Biaya,
 	})
-
 }
 
 func (c *allController) GetSaldo(ctx echo.Context) error {
@@ -265,16 +179,9 @@ func (c *allController) GetSaldo(ctx echo.Context) error {
 		"layer":       "allController",
 	}).Info("Menerima permintaan cek saldo")
 
-	rekening, err := c.AllUsecase.FindByNoREK(noREK)
-	if err != nil || rekening.ID == 0 {
-		utils.Log.WithError(err).WithFields(logrus.Fields{
-			"no_rekening": noREK,
-			"action":      "GetSaldo",
-			"layer":       "allController",
-		}).Warn("Rekening tidak ditemukan saat cek saldo")
-		return ctx.JSON(http.StatusBadRequest, map[string]string{
-			"remark": "rekening tidak ditemukan",
-		})
+	rekening, err := c.authorizeRekening(ctx, noREK)
+	if err != nil {
+		return err
 	}
 
 	utils.Log.WithFields(logrus.Fields{
@@ -288,6 +195,546 @@ func (c *allController) GetSaldo(ctx echo.Context) error {
 	})
 }
 
-func NewController(AllUsecase usecase.AllUsecase) AllController {
-	return &allController{AllUsecase}
+func (c *allController) Transfer(ctx echo.Context) error {
+	var newTransfer model.Transfer
+
+	utils.Log.WithFields(logrus.Fields{
+		"action": "bind data transfer",
+		"layer":  "allController",
+	}).Info("Mencoba memproses data req transfer")
+	if err := bind(ctx, &newTransfer); err != nil {
+		return err
+	}
+	newTransfer.IdempotencyKeyID = middleware.IdempotencyKeyID(ctx)
+
+	if newTransfer.NoRekeningAsal == "" || newTransfer.NoRekeningTujuan == "" {
+		return usecase.NewValidationError("Field no_rekening_asal dan no_rekening_tujuan wajib diisi")
+	}
+
+	if _, err := c.authorizeRekening(ctx, newTransfer.NoRekeningAsal); err != nil {
+		return err
+	}
+
+	createdTransfer, err := c.AllUsecase.Transfer(newTransfer)
+	if err != nil {
+		return err
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"no_referensi": createdTransfer.Transaksi.NoReferensi,
+		"action":       "transfer",
+		"layer":        "allController",
+	}).Info("Berhasil melakukan transfer")
+	return ctx.JSON(http.StatusOK, map[string]interface{}{
+		"no_referensi": createdTransfer.Transaksi.NoReferensi,
+		"saldo":        createdTransfer.Saldo,
+		"biaya":        createdTransfer.Biaya,
+	})
+}
+
+func (c *allController) Mutasi(ctx echo.Context) error {
+	filter := model.MutasiFilter{
+		NoRekening:     ctx.Param("no_rekening"),
+		JenisTransaksi: ctx.QueryParam("jenis_transaksi"),
+		Sort:           ctx.QueryParam("sort"),
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"no_rekening": filter.NoRekening,
+		"action":      "Mutasi",
+		"layer":       "allController",
+	}).Info("Menerima permintaan mutasi rekening")
+
+	if _, err := c.authorizeRekening(ctx, filter.NoRekening); err != nil {
+		return err
+	}
+
+	var err error
+	if filter.Page, err = queryInt(ctx, "page"); err != nil {
+		return usecase.NewValidationError("page harus berupa angka")
+	}
+	if filter.Limit, err = queryInt(ctx, "limit"); err != nil {
+		return usecase.NewValidationError("limit harus berupa angka")
+	}
+	if filter.Dari, err = queryDate(ctx, "from"); err != nil {
+		return usecase.NewValidationError("from harus berformat YYYY-MM-DD")
+	}
+	if filter.Sampai, err = queryDate(ctx, "to"); err != nil {
+		return usecase.NewValidationError("to harus berformat YYYY-MM-DD")
+	}
+	if filter.Sampai != nil {
+		// tanggal "to" inklusif, jadi batas atasnya awal hari berikutnya
+		sampai := filter.Sampai.AddDate(0, 0, 1)
+		filter.Sampai = &sampai
+	}
+
+	mutasi, err := c.AllUsecase.Mutasi(filter)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, mutasi)
+}
+
+func (c *allController) Login(ctx echo.Context) error {
+	var login model.Login
+
+	if err := bind(ctx, &login); err != nil {
+		return err
+	}
+	if login.NoHP == "" || login.Password == "" {
+		return usecase.NewValidationError("Field no_hp dan password wajib diisi")
+	}
+
+	token, err := c.AllUsecase.Login(login)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, token)
+}
+
+func (c *allController) UbahPin(ctx echo.Context) error {
+	var ubah model.UbahPin
+
+	if err := bind(ctx, &ubah); err != nil {
+		return err
+	}
+
+	if err := c.AllUsecase.UbahPin(middleware.NasabahID(ctx), ubah); err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, map[string]string{"remark": "PIN berhasil diubah"})
+}
+
+func (c *allController) ResetPin(ctx echo.Context) error {
+	var reset model.ResetPin
+
+	if err := bind(ctx, &reset); err != nil {
+		return err
+	}
+
+	if err := c.AllUsecase.ResetPin(middleware.NasabahID(ctx), reset); err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, map[string]string{"remark": "PIN berhasil direset"})
+}
+
+func (c *allController) BukaRekening(ctx echo.Context) error {
+	var bukaRekening model.BukaRekening
+
+	if err := bind(ctx, &bukaRekening); err != nil {
+		return err
+	}
+	if bukaRekening.KodeProduk == "" {
+		return usecase.NewValidationError("Field kode_produk wajib diisi")
+	}
+
+	rekening, err := c.AllUsecase.BukaRekening(middleware.NasabahID(ctx), bukaRekening)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusCreated, rekening)
+}
+
+func (c *allController) UbahStatusRekening(ctx echo.Context) error {
+	var ubah model.UbahStatusRekening
+
+	if err := bind(ctx, &ubah); err != nil {
+		return err
+	}
+	if ubah.Status == "" {
+		return usecase.NewValidationError("Field status wajib diisi")
+	}
+	if ubah.Alasan == "" {
+		return usecase.NewValidationError("Field alasan wajib diisi")
+	}
+
+	rekening, err := c.AllUsecase.UbahStatusRekening(ctx.Param("no_rekening"), ubah, middleware.Operator(ctx))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, rekening)
+}
+
+func (c *allController) GetNasabah(ctx echo.Context) error {
+	nasabahID, err := ownNasabahID(ctx)
+	if err != nil {
+		return err
+	}
+
+	nasabah, err := c.AllUsecase.FindNasabahByID(nasabahID)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, nasabah)
+}
+
+func (c *allController) UbahProfil(ctx echo.Context) error {
+	nasabahID, err := ownNasabahID(ctx)
+	if err != nil {
+		return err
+	}
+
+	var ubah model.UbahProfil
+	if err := bind(ctx, &ubah); err != nil {
+		return err
+	}
+	if ubah.Nama == nil && ubah.NoHP == nil {
+		return usecase.NewValidationError("Field nama atau no_hp wajib diisi")
+	}
+
+	nasabah, err := c.AllUsecase.UbahProfil(nasabahID, ubah, fmt.Sprintf("nasabah:%d", nasabahID))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, nasabah)
+}
+
+func (c *allController) RiwayatNasabah(ctx echo.Context) error {
+	nasabahID, err := ownNasabahID(ctx)
+	if err != nil {
+		return err
+	}
+
+	riwayat, err := c.AllUsecase.RiwayatNasabah(nasabahID)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, map[string]interface{}{
+		"nasabah_id": nasabahID,
+		"riwayat":    riwayat,
+	})
+}
+
+// CariNasabah adalah endpoint back-office untuk mencari nasabah berdasarkan NIK
+func (c *allController) CariNasabah(ctx echo.Context) error {
+	nik := ctx.QueryParam("nik")
+	if nik == "" {
+		return usecase.NewValidationError("Query nik wajib diisi")
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"nik":      nik,
+		"operator": middleware.Operator(ctx),
+		"action":   "CariNasabah",
+		"layer":    "allController",
+	}).Info("Petugas mencari nasabah berdasarkan NIK")
+	nasabah, err := c.AllUsecase.FindNasabahByNIK(nik)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, nasabah)
+}
+
+func (c *allController) AjukanKYC(ctx echo.Context) error {
+	nasabahID, err := ownNasabahID(ctx)
+	if err != nil {
+		return err
+	}
+
+	var ajuan model.AjukanKYC
+	if err := bind(ctx, &ajuan); err != nil {
+		return err
+	}
+
+	nasabah, err := c.AllUsecase.AjukanKYC(nasabahID, ajuan)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, nasabah)
+}
+
+func (c *allController) AntreanKYC(ctx echo.Context) error {
+	nasabah, err := c.AllUsecase.AntreanKYC()
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, map[string]interface{}{
+		"nasabah": nasabah,
+	})
+}
+
+func (c *allController) SetujuiKYC(ctx echo.Context) error {
+	return c.verifikasiKYC(ctx, true)
+}
+
+func (c *allController) TolakKYC(ctx echo.Context) error {
+	return c.verifikasiKYC(ctx, false)
+}
+
+func (c *allController) verifikasiKYC(ctx echo.Context, setuju bool) error {
+	nasabahID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return usecase.NewValidationError("id nasabah harus berupa angka")
+	}
+
+	var verifikasi model.VerifikasiKYC
+	if err := bind(ctx, &verifikasi); err != nil {
+		return err
+	}
+
+	nasabah, err := c.AllUsecase.VerifikasiKYC(nasabahID, setuju, verifikasi, middleware.Operator(ctx))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, nasabah)
+}
+
+func (c *allController) Reversal(ctx echo.Context) error {
+	transaksiID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return usecase.NewValidationError("id transaksi harus berupa angka")
+	}
+
+	var reversal model.Reversal
+	if err := bind(ctx, &reversal); err != nil {
+		return err
+	}
+	if reversal.KodeAlasan == "" {
+		return usecase.NewValidationError("Field kode_alasan wajib diisi")
+	}
+
+	koreksi, err := c.AllUsecase.Reversal(transaksiID, reversal, middleware.Operator(ctx))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, map[string]interface{}{
+		"transaksi_asal_id": transaksiID,
+		"koreksi":           koreksi,
+	})
+}
+
+func (c *allController) JalankanRekonsiliasi(ctx echo.Context) error {
+	var req model.JalankanRekonsiliasi
+	if err := bind(ctx, &req); err != nil {
+		return err
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"operator": middleware.Operator(ctx),
+		"bekukan":  req.Bekukan,
+		"action":   "JalankanRekonsiliasi",
+		"layer":    "allController",
+	}).Info("Rekonsiliasi dijalankan dari endpoint admin")
+
+	run, err := c.RekonsiliasiUsecase.Jalankan(model.PemicuAdmin, req.Bekukan)
+	if err != nil {
+		return err
+	}
+	// ambil ulang agar response memuat daftar selisih yang baru ditulis
+	run, err = c.RekonsiliasiUsecase.FindByID(run.ID)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, run)
+}
+
+func (c *allController) ListRekonsiliasi(ctx echo.Context) error {
+	runs, err := c.RekonsiliasiUsecase.FindTerbaru()
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, map[string]interface{}{
+		"rekonsiliasi": runs,
+	})
+}
+
+func (c *allController) GetRekonsiliasi(ctx echo.Context) error {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return usecase.NewValidationError("id rekonsiliasi harus berupa angka")
+	}
+
+	run, err := c.RekonsiliasiUsecase.FindByID(id)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, run)
+}
+
+func (c *allController) TutupHari(ctx echo.Context) error {
+	var req model.JalankanTutupHari
+	if err := bind(ctx, &req); err != nil {
+		return err
+	}
+
+	var (
+		tanggal time.Time
+		err     error
+	)
+	if req.Tanggal == "" {
+		tanggal, err = c.TutupHariUsecase.TanggalBerikutnya()
+		if err != nil {
+			return err
+		}
+	} else {
+		tanggal, err = time.ParseInLocation("2006-01-02", req.Tanggal, time.Local)
+		if err != nil {
+			return usecase.NewValidationError("tanggal harus berformat YYYY-MM-DD")
+		}
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"operator": middleware.Operator(ctx),
+		"tanggal":  tanggal.Format("2006-01-02"),
+		"action":   "TutupHari",
+		"layer":    "allController",
+	}).Info("Tutup hari dijalankan dari endpoint admin")
+
+	tutupHari, err := c.TutupHariUsecase.Jalankan(tanggal, model.PemicuAdmin)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, tutupHari)
+}
+
+func (c *allController) GetTutupHari(ctx echo.Context) error {
+	tanggal, err := time.ParseInLocation("2006-01-02", ctx.Param("tanggal"), time.Local)
+	if err != nil {
+		return usecase.NewValidationError("tanggal harus berformat YYYY-MM-DD")
+	}
+
+	tutupHari, err := c.TutupHariUsecase.FindByTanggal(tanggal)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, tutupHari)
+}
+
+func (c *allController) ProsesBunga(ctx echo.Context) error {
+	utils.Log.WithFields(logrus.Fields{
+		"operator": middleware.Operator(ctx),
+		"action":   "ProsesBunga",
+		"layer":    "allController",
+	}).Info("Proses bunga dijalankan dari endpoint admin")
+
+	hasil, err := c.BungaUsecase.Proses()
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, hasil)
+}
+
+func (c *allController) TagihBiayaAdmin(ctx echo.Context) error {
+	var req model.TagihBiayaAdmin
+	if err := bind(ctx, &req); err != nil {
+		return err
+	}
+
+	periode := c.BiayaUsecase.PeriodeTerakhir()
+	if req.Periode != "" {
+		var err error
+		periode, err = time.ParseInLocation("2006-01", req.Periode, time.Local)
+		if err != nil {
+			return usecase.NewValidationError("periode harus berformat YYYY-MM")
+		}
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"operator": middleware.Operator(ctx),
+		"periode":  periode.Format("2006-01"),
+		"action":   "TagihBiayaAdmin",
+		"layer":    "allController",
+	}).Info("Penagihan biaya admin dijalankan dari endpoint admin")
+
+	hasil, err := c.BiayaUsecase.TagihAdminBulanan(periode)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, hasil)
+}
+
+func (c *allController) ListTunggakan(ctx echo.Context) error {
+	tunggakan, err := c.BiayaUsecase.FindTunggakan(ctx.Param("no_rekening"))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, map[string]interface{}{
+		"tunggakan": tunggakan,
+	})
+}
+
+func (c *allController) ListRekening(ctx echo.Context) error {
+	nasabahID, err := ownNasabahID(ctx)
+	if err != nil {
+		return err
+	}
+
+	rekening, err := c.AllUsecase.FindAllRekeningByNasabahID(nasabahID)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, map[string]interface{}{
+		"rekening": rekening,
+	})
+}
+
+// ownNasabahID membaca parameter :id dan memastikan nasabah hanya mengakses datanya sendiri
+func ownNasabahID(ctx echo.Context) (int, error) {
+	nasabahID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		return 0, usecase.NewValidationError("id nasabah harus berupa angka")
+	}
+	if nasabahID != middleware.NasabahID(ctx) {
+		return 0, usecase.ErrNasabahForbidden
+	}
+	return nasabahID, nil
+}
+
+// authorizeRekening memastikan rekening ada dan dimiliki nasabah pemilik token
+func (c *allController) authorizeRekening(ctx echo.Context, noREK string) (model.Rekening, error) {
+	rekening, err := c.AllUsecase.FindByNoREK(noREK)
+	if err != nil {
+		return model.Rekening{}, err
+	}
+	if rekening.ID == 0 {
+		return model.Rekening{}, usecase.ErrAccountNotFound
+	}
+
+	if rekening.NasabahID != middleware.NasabahID(ctx) {
+		utils.Log.WithFields(logrus.Fields{
+			"no_rekening": noREK,
+			"nasabah_id":  middleware.NasabahID(ctx),
+			"action":      "authorize rekening",
+			"layer":       "allController",
+		}).Warn("Nasabah mencoba mengakses rekening milik nasabah lain")
+		return model.Rekening{}, usecase.ErrAccountForbidden
+	}
+	return rekening, nil
+}
+
+func bind(ctx echo.Context, target interface{}) error {
+	if err := ctx.Bind(target); err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"path":  ctx.Path(),
+			"layer": "allController",
+		}).Error("Format data req tidak valid")
+		return usecase.ErrInvalidFormat
+	}
+	return nil
+}
+
+func queryInt(ctx echo.Context, name string) (int, error) {
+	value := ctx.QueryParam(name)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func queryDate(ctx echo.Context, name string) (*time.Time, error) {
+	value := ctx.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+func NewController(AllUsecase usecase.AllUsecase, RekonsiliasiUsecase usecase.RekonsiliasiUsecase, TutupHariUsecase usecase.TutupHariUsecase, BungaUsecase usecase.BungaUsecase, BiayaUsecase usecase.BiayaUsecase) AllController {
+	return &allController{AllUsecase, RekonsiliasiUsecase, TutupHariUsecase, BungaUsecase, BiayaUsecase}
 }
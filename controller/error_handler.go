@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sferawann/go-bank-api/usecase"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+type ErrorResponse struct {
+	Code   string            `json:"code"`
+	Remark string            `json:"remark"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+var kindStatus = map[usecase.ErrorKind]int{
+	usecase.KindValidation:   http.StatusBadRequest,
+	usecase.KindUnauthorized: http.StatusUnauthorized,
+	usecase.KindForbidden:    http.StatusForbidden,
+	usecase.KindNotFound:     http.StatusNotFound,
+	usecase.KindConflict:     http.StatusConflict,
+	usecase.KindBusinessRule: http.StatusUnprocessableEntity,
+}
+
+// HTTPErrorHandler adalah satu-satunya tempat error diubah menjadi response HTTP.
+// DomainError dipetakan berdasarkan Kind, echo.HTTPError mengikuti status bawaannya,
+// dan error lain dianggap kesalahan server tanpa membocorkan pesan aslinya.
+func HTTPErrorHandler(err error, ctx echo.Context) {
+	if ctx.Response().Committed {
+		return
+	}
+
+	status, body := errorResponse(err)
+	fields := logrus.Fields{
+		"method": ctx.Request().Method,
+		"path":   ctx.Path(),
+		"status": status,
+		"code":   body.Code,
+		"layer":  "errorHandler",
+	}
+	if status >= http.StatusInternalServerError {
+		utils.Log.WithError(err).WithFields(fields).Error("Terjadi kesalahan pada server")
+	} else {
+		utils.Log.WithError(err).WithFields(fields).Warn("Permintaan ditolak")
+	}
+
+	if ctx.Request().Method == http.MethodHead {
+		err = ctx.NoContent(status)
+	} else {
+		err = ctx.JSON(status, body)
+	}
+	if err != nil {
+		utils.Log.WithError(err).WithFields(fields).Error("Gagal mengirim response error")
+	}
+}
+
+func errorResponse(err error) (int, ErrorResponse) {
+	if domainErr, ok := usecase.AsDomainError(err); ok {
+		status, known := kindStatus[domainErr.Kind]
+		if !known {
+			status = http.StatusInternalServerError
+		}
+		return status, ErrorResponse{Code: domainErr.Code, Remark: domainErr.Message, Fields: domainErr.Fields}
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code, ErrorResponse{
+			Code:   fmt.Sprintf("HTTP_%d", httpErr.Code),
+			Remark: fmt.Sprint(httpErr.Message),
+		}
+	}
+
+	return http.StatusInternalServerError, ErrorResponse{
+		Code:   "INTERNAL_ERROR",
+		Remark: "Terjadi kesalahan pada server",
+	}
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"os"
+
 	"github.com/labstack/echo/v4"
 	"github.com/sferawann/go-bank-api/config"
 	"github.com/sferawann/go-bank-api/controller"
+	"github.com/sferawann/go-bank-api/middleware"
 	"github.com/sferawann/go-bank-api/repository"
 	"github.com/sferawann/go-bank-api/router"
 	"github.com/sferawann/go-bank-api/usecase"
@@ -13,18 +16,51 @@ import (
 func main() {
 	utils.SetupLogger()
 
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	config.InitDB()
+	config.InitAuth()
+	config.InitRekening()
+	config.InitAdmin()
+	config.InitRekonsiliasi()
+	config.InitBunga()
+	config.InitIdempotency()
 	db := config.DB
 
 	nasabahRepo := repository.NewNasabahRepository(db)
+	nasabahRiwayatRepo := repository.NewNasabahRiwayatRepository(db)
 	rekeningRepo := repository.NewRekeningRepository(db)
 	transaksiRepo := repository.NewTransaksiRepository(db)
+	unitOfWork := repository.NewUnitOfWork(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	rekonsiliasiRepo := repository.NewRekonsiliasiRepository(db)
+	tutupHariRepo := repository.NewTutupHariRepository(db)
+	bungaRepo := repository.NewBungaRepository(db)
+	biayaRepo := repository.NewBiayaRepository(db)
+
+	tokenManager := utils.NewTokenManager(config.AuthSecret, config.TokenTTL)
+	clock := utils.NewSystemClock()
+	noRekGenerator, err := utils.NewNoRekGenerator(config.KodeCabang)
+	if err != nil {
+		utils.Log.Fatal("konfigurasi nomor rekening tidak valid: ", err)
+	}
+
+	rekonsiliasiUsecase := usecase.NewRekonsiliasiUsecase(rekeningRepo, rekonsiliasiRepo, unitOfWork, clock)
+	tutupHariUsecase := usecase.NewTutupHariUsecase(rekeningRepo, transaksiRepo, tutupHariRepo, unitOfWork, clock)
+	bungaUsecase := usecase.NewBungaUsecase(tutupHariRepo, bungaRepo, unitOfWork, clock, config.PajakBungaPersen, config.PajakBungaBatasSaldo)
+	biayaUsecase := usecase.NewBiayaUsecase(rekeningRepo, biayaRepo, unitOfWork, clock)
+	usecase := usecase.NewUsecase(nasabahRepo, nasabahRiwayatRepo, rekeningRepo, transaksiRepo, unitOfWork, tokenManager, noRekGenerator, config.PinMaxGagal, clock)
+	allController := controller.NewController(usecase, rekonsiliasiUsecase, tutupHariUsecase, bungaUsecase, biayaUsecase)
 
-	usecase := usecase.NewUsecase(nasabahRepo, rekeningRepo, transaksiRepo)
-	controller := controller.NewController(usecase)
+	if config.RekonsiliasiInterval > 0 {
+		go jadwalRekonsiliasi(rekonsiliasiUsecase, config.RekonsiliasiInterval, config.RekonsiliasiBekukan)
+	}
 
 	e := echo.New()
-	router.NewRouter(e, controller)
+	e.HTTPErrorHandler = controller.HTTPErrorHandler
+	router.NewRouter(e, allController, middleware.Auth(tokenManager), middleware.Admin(config.AdminAPIKey), middleware.Idempotency(idempotencyRepo, config.IdempotencyProsesTTL))
 
 	utils.Log.Infof("Aplikasi berjalan di port :8080")
 	e.Logger.Fatal(e.Start(":8080"))
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sferawann/go-bank-api/usecase"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	HeaderAdminKey  = "X-Admin-Key"
+	HeaderOperator  = "X-Operator"
+	contextOperator = "operator"
+)
+
+// Admin memverifikasi header X-Admin-Key dan mewajibkan X-Operator berisi identitas
+// petugas yang melakukan aksi, untuk dicatat di audit
+func Admin(apiKey []byte) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			key := []byte(ctx.Request().Header.Get(HeaderAdminKey))
+			if len(key) == 0 || subtle.ConstantTimeCompare(key, apiKey) != 1 {
+				utils.Log.WithFields(logrus.Fields{
+					"path":   ctx.Path(),
+					"action": "admin auth",
+					"layer":  "middleware",
+				}).Warn("Kunci admin tidak valid")
+				return usecase.ErrAdminUnauthenticated
+			}
+
+			operator := strings.TrimSpace(ctx.Request().Header.Get(HeaderOperator))
+			if operator == "" {
+				return usecase.ErrOperatorRequired
+			}
+
+			ctx.Set(contextOperator, operator)
+			return next(ctx)
+		}
+	}
+}
+
+// Operator mengembalikan identitas petugas admin dari header X-Operator
+func Operator(ctx echo.Context) string {
+	operator, _ := ctx.Get(contextOperator).(string)
+	return operator
+}
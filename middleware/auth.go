@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sferawann/go-bank-api/usecase"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const contextNasabahID = "nasabah_id"
+
+// Auth memverifikasi header "Authorization: Bearer <token>" dan menyimpan ID nasabah
+// pemilik token di context
+func Auth(tokenManager *utils.TokenManager) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			token, ok := strings.CutPrefix(ctx.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if !ok || token == "" {
+				return usecase.ErrUnauthenticated
+			}
+
+			nasabahID, err := tokenManager.Parse(token)
+			if err != nil {
+				utils.Log.WithError(err).WithFields(logrus.Fields{
+					"path":   ctx.Path(),
+					"action": "auth",
+					"layer":  "middleware",
+				}).Warn("Token tidak valid")
+				return usecase.ErrUnauthenticated
+			}
+
+			ctx.Set(contextNasabahID, nasabahID)
+			return next(ctx)
+		}
+	}
+}
+
+// NasabahID mengembalikan ID nasabah yang sudah diautentikasi, atau 0 jika tidak ada
+func NasabahID(ctx echo.Context) int {
+	nasabahID, _ := ctx.Get(contextNasabahID).(int)
+	return nasabahID
+}
@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+	"github.com/sferawann/go-bank-api/usecase"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+const (
+	contextIdempotencyKeyID = "idempotency_key_id"
+	// key yang sedang diproses diperpanjang beberapa kali dalam satu prosesTTL
+	perpanjangPerProsesTTL = 3
+)
+
+// Idempotency menyimpan response pertama untuk setiap Idempotency-Key sehingga retry dengan
+// key dan payload yang sama mendapat response yang sama tanpa menjalankan handler lagi.
+// Key yang dipakai ulang dengan payload berbeda ditolak. Selama handler berjalan key terus
+// diperpanjang, jadi hanya key yang tidak diperpanjang selama prosesTTL (prosesnya mati) yang
+// boleh diambil alih oleh retry. Handler yang memindahkan uang menyimpan ID key di transaksinya
+// dalam transaksi database yang sama, sehingga retry yang mengambil alih mendapat transaksi yang
+// sudah ter-commit alih-alih mengulangnya.
+func Idempotency(repo repository.IdempotencyRepository, prosesTTL time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			kunci := ctx.Request().Header.Get(HeaderIdempotencyKey)
+			if kunci == "" {
+				return next(ctx)
+			}
+			endpoint := ctx.Request().Method + " " + ctx.Path()
+			if nasabahID := NasabahID(ctx); nasabahID != 0 {
+				endpoint += " nasabah:" + strconv.Itoa(nasabahID)
+			}
+
+			body, err := io.ReadAll(ctx.Request().Body)
+			if err != nil {
+				return usecase.ErrInvalidFormat
+			}
+			ctx.Request().Body = io.NopCloser(bytes.NewReader(body))
+			hash := sha256.Sum256(body)
+			requestHash := hex.EncodeToString(hash[:])
+
+			logFields := logrus.Fields{
+				"kunci":    kunci,
+				"endpoint": endpoint,
+				"action":   "idempotency",
+				"layer":    "middleware",
+			}
+
+			existing, err := repo.FindByKunci(kunci, endpoint)
+			if err != nil {
+				return err
+			}
+			if existing.ID != 0 {
+				if existing.StatusCode == 0 && existing.RequestHash == requestHash {
+					diambil, err := repo.AmbilAlih(existing.ID, time.Now().Add(-prosesTTL))
+					if err != nil {
+						return err
+					}
+					if diambil {
+						utils.Log.WithFields(logFields).Warn("Idempotency key tertinggal dalam status diproses, diambil alih oleh retry")
+						return proses(ctx, next, repo, existing, prosesTTL, logFields)
+					}
+				}
+				return replay(ctx, existing, requestHash, logFields)
+			}
+
+			record, err := repo.Create(model.IdempotencyKey{
+				Kunci:       kunci,
+				Endpoint:    endpoint,
+				RequestHash: requestHash,
+			})
+			if repository.IsDuplicate(err, repository.ConstraintIdempotencyKunci) {
+				// request lain dengan key yang sama menang lebih dulu
+				utils.Log.WithError(err).WithFields(logFields).Warn("Idempotency key sedang dipakai request lain")
+				return usecase.ErrIdempotencyKeyInProgress
+			}
+			if err != nil {
+				return err
+			}
+			return proses(ctx, next, repo, record, prosesTTL, logFields)
+		}
+	}
+}
+
+// IdempotencyKeyID mengembalikan ID Idempotency-Key yang dipegang request ini, atau nil jika
+// request tidak membawa key
+func IdempotencyKeyID(ctx echo.Context) *int {
+	id, ok := ctx.Get(contextIdempotencyKeyID).(int)
+	if !ok {
+		return nil
+	}
+	return &id
+}
+
+// proses menjalankan handler untuk key yang sudah dipegang request ini lalu menyimpan
+// response-nya. Jika handler gagal karena server atau panic, key dilepas agar klien bisa
+// mencoba lagi.
+func proses(ctx echo.Context, next echo.HandlerFunc, repo repository.IdempotencyRepository, record model.IdempotencyKey, prosesTTL time.Duration, logFields logrus.Fields) error {
+	lepas := func() {
+		if err := repo.Delete(record.ID); err != nil {
+			utils.Log.WithError(err).WithFields(logFields).Error("Gagal melepas idempotency key")
+		}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			lepas()
+			panic(r)
+		}
+	}()
+
+	selesai := make(chan struct{})
+	defer close(selesai)
+	go perpanjang(repo, record.ID, prosesTTL/perpanjangPerProsesTTL, selesai, logFields)
+
+	ctx.Set(contextIdempotencyKeyID, record.ID)
+	recorder := &bodyRecorder{ResponseWriter: ctx.Response().Writer}
+	ctx.Response().Writer = recorder
+
+	// error dirender di sini supaya response penolakan bisnis (4xx) ikut tersimpan
+	if err := next(ctx); err != nil {
+		ctx.Error(err)
+	}
+	status := ctx.Response().Status
+	if status >= http.StatusInternalServerError {
+		lepas()
+		return nil
+	}
+
+	if err := repo.Complete(record.ID, status, recorder.body.String()); err != nil {
+		utils.Log.WithError(err).WithFields(logFields).Error("Gagal menyimpan response idempotency key")
+	}
+	return nil
+}
+
+// perpanjang memperbarui key secara berkala sampai selesai ditutup, supaya request yang lambat
+// (misalnya menunggu kunci rekening) tidak dianggap mati dan diambil alih
+func perpanjang(repo repository.IdempotencyRepository, id int, interval time.Duration, selesai <-chan struct{}, logFields logrus.Fields) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-selesai:
+			return
+		case <-ticker.C:
+			if err := repo.Perpanjang(id); err != nil {
+				utils.Log.WithError(err).WithFields(logFields).Error("Gagal memperpanjang idempotency key")
+			}
+		}
+	}
+}
+
+func replay(ctx echo.Context, existing model.IdempotencyKey, requestHash string, logFields logrus.Fields) error {
+	if existing.RequestHash != requestHash {
+		utils.Log.WithFields(logFields).Warn("Idempotency key dipakai ulang dengan payload berbeda")
+		return usecase.ErrIdempotencyKeyReused
+	}
+	if existing.StatusCode == 0 {
+		return usecase.ErrIdempotencyKeyInProgress
+	}
+
+	utils.Log.WithFields(logFields).Info("Mengembalikan response tersimpan untuk idempotency key")
+	ctx.Response().Header().Set("Idempotent-Replayed", "true")
+	return ctx.Blob(existing.StatusCode, echo.MIMEApplicationJSONCharsetUTF8, []byte(existing.ResponseBody))
+}
+
+type bodyRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AuditPinGagal    = "pin_gagal"
+	AuditPinTerkunci = "pin_terkunci"
+	AuditPinDiubah   = "pin_diubah"
+	AuditPinDireset  = "pin_direset"
+
+	AuditStatusRekening = "status_rekening_diubah"
+
+	AuditKYCDiajukan  = "kyc_diajukan"
+	AuditKYCDisetujui = "kyc_disetujui"
+	AuditKYCDitolak   = "kyc_ditolak"
+
+	AuditReversal = "transaksi_dikoreksi"
+
+	AuditNasabahDibuat  = "nasabah_dibuat"
+	AuditRekeningDibuka = "rekening_dibuka"
+	AuditProfilDiubah   = "profil_diubah"
+	AuditSaldoBerubah   = "saldo_berubah"
+)
+
+// GenesisHash adalah prev_hash untuk entri pertama setiap rantai audit
+var GenesisHash = strings.Repeat("0", sha256.Size*2)
+
+// RantaiRekening adalah kunci rantai audit untuk perubahan saldo sebuah rekening
+func RantaiRekening(rekeningID int) string {
+	return "rekening:" + strconv.Itoa(rekeningID)
+}
+
+// RantaiNasabah adalah kunci rantai audit untuk kejadian nasabah selain perubahan saldo
+func RantaiNasabah(nasabahID int) string {
+	return "nasabah:" + strconv.Itoa(nasabahID)
+}
+
+type AuditEvent struct {
+	ID         int       `gorm:"column:id;primaryKey" json:"id"`
+	Rantai     string    `gorm:"column:rantai" json:"rantai"`
+	NasabahID  *int      `gorm:"column:nasabah_id" json:"nasabah_id"`
+	Jenis      string    `gorm:"column:jenis" json:"jenis"`
+	Keterangan string    `gorm:"column:keterangan" json:"keterangan"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`
+	PrevHash   string    `gorm:"column:prev_hash" json:"prev_hash"`
+	Hash       string    `gorm:"column:hash" json:"hash"`
+}
+
+func (AuditEvent) TableName() string {
+	return "audit_event"
+}
+
+// ComputeHash menghitung SHA-256 dari isi entri beserta Rantai dan PrevHash. Field di-encode sebagai JSON
+// supaya batas antar field tidak ambigu, dan waktu selalu ditulis dalam UTC presisi mikrodetik
+// sesuai yang tersimpan di kolom TIMESTAMPTZ.
+func (e AuditEvent) ComputeHash() string {
+	payload, _ := json.Marshal(struct {
+		Rantai     string `json:"rantai"`
+		PrevHash   string `json:"prev_hash"`
+		NasabahID  *int   `json:"nasabah_id"`
+		Jenis      string `json:"jenis"`
+		Keterangan string `json:"keterangan"`
+		CreatedAt  string `json:"created_at"`
+	}{
+		Rantai:     e.Rantai,
+		PrevHash:   e.PrevHash,
+		NasabahID:  e.NasabahID,
+		Jenis:      e.Jenis,
+		Keterangan: e.Keterangan,
+		CreatedAt:  e.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditVerifikasi adalah hasil pemeriksaan rantai hash audit_event
+type AuditVerifikasi struct {
+	JumlahEntri int      `json:"jumlah_entri"`
+	Valid       bool     `json:"valid"`
+	Temuan      []string `json:"temuan,omitempty"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type Login struct {
+	NoHP     string `json:"no_hp"`
+	Password string `json:"password"`
+}
+
+type Token struct {
+	Token     string    `json:"token"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+const (
+	BiayaAdminBulanan = "admin_bulanan"
+	BiayaTarik        = "tarik"
+	BiayaTransfer     = "transfer"
+)
+
+// Kebijakan jika saldo tidak cukup membayar biaya penuh: lewati tidak menagih sama sekali,
+// sebagian menagih sisa saldo dan menghapus kekurangannya, tunggakan menagih sisa saldo dan
+// mencatat kekurangannya untuk ditagih lagi nanti
+const (
+	KebijakanLewati    = "lewati"
+	KebijakanSebagian  = "sebagian"
+	KebijakanTunggakan = "tunggakan"
+)
+
+const (
+	StatusTagihanLunas     = "lunas"
+	StatusTagihanSebagian  = "sebagian"
+	StatusTagihanDilewati  = "dilewati"
+	StatusTagihanTunggakan = "tunggakan"
+)
+
+// StatusTagihanDibatalkan menandai tagihan yang transaksi biayanya atau transaksi pemicunya
+// dikoreksi. Sisanya tidak ditagih lagi.
+const StatusTagihanDibatalkan = "dibatalkan"
+
+// TagihanBiaya adalah satu biaya yang dikenakan ke rekening beserta berapa yang sudah tertagih
+type TagihanBiaya struct {
+	ID                int        `gorm:"column:id;primaryKey" json:"id"`
+	RekeningID        int        `gorm:"column:rekening_id" json:"rekening_id"`
+	JenisBiaya        string     `gorm:"column:jenis_biaya" json:"jenis_biaya"`
+	Periode           *time.Time `gorm:"column:periode;type:date" json:"periode,omitempty"`
+	TransaksiPemicuID *int       `gorm:"column:transaksi_pemicu_id" json:"transaksi_pemicu_id,omitempty"`
+	Nominal           Money      `gorm:"column:nominal;type:decimal(15,2)" json:"nominal"`
+	Tertagih          Money      `gorm:"column:tertagih;type:decimal(15,2)" json:"tertagih"`
+	Status            string     `gorm:"column:status" json:"status"`
+	CreatedAt         time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt         time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (TagihanBiaya) TableName() string {
+	return "tagihan_biaya"
+}
+
+// Sisa adalah bagian biaya yang belum tertagih
+func (t TagihanBiaya) Sisa() Money {
+	return t.Nominal - t.Tertagih
+}
+
+// HasilBiayaAdmin adalah ringkasan penagihan biaya admin satu periode
+type HasilBiayaAdmin struct {
+	Periode        string `json:"periode"`
+	JumlahRekening int    `json:"jumlah_rekening"`
+	TotalTertagih  Money  `json:"total_tertagih"`
+}
+
+type TagihBiayaAdmin struct {
+	Periode string `json:"periode"`
+}
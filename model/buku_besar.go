@@ -0,0 +1,66 @@
+package model
+
+import "time"
+
+// Kode akun internal yang tidak terikat ke rekening nasabah
+const (
+	AkunKas             = "KAS"
+	AkunSuspense        = "SUSPENSE"
+	AkunBebanBunga      = "BEBAN_BUNGA"
+	AkunUtangPajak      = "UTANG_PAJAK"
+	AkunPendapatanBiaya = "PENDAPATAN_BIAYA"
+)
+
+const (
+	JenisAkunAset       = "aset"
+	JenisAkunKewajiban  = "kewajiban"
+	JenisAkunPendapatan = "pendapatan"
+	JenisAkunBeban      = "beban"
+)
+
+// AkunBuku adalah akun di buku besar. Akun rekening nasabah berjenis kewajiban
+// dan memiliki RekeningID, akun internal tidak.
+type AkunBuku struct {
+	ID         int       `gorm:"column:id;primaryKey" json:"id"`
+	Kode       string    `gorm:"column:kode" json:"kode"`
+	Nama       string    `gorm:"column:nama" json:"nama"`
+	Jenis      string    `gorm:"column:jenis" json:"jenis"`
+	RekeningID *int      `gorm:"column:rekening_id" json:"rekening_id,omitempty"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (AkunBuku) TableName() string {
+	return "akun_buku"
+}
+
+// KodeAkunRekening adalah kode akun buku besar untuk rekening nasabah
+func KodeAkunRekening(noRekening string) string {
+	return "REK-" + noRekening
+}
+
+// Jurnal mengelompokkan posting yang jumlah debit dan kreditnya harus sama
+type Jurnal struct {
+	ID          int       `gorm:"column:id;primaryKey" json:"id"`
+	NoReferensi string    `gorm:"column:no_referensi;default:null" json:"no_referensi,omitempty"`
+	Keterangan  string    `gorm:"column:keterangan" json:"keterangan"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+	Posting     []Posting `gorm:"foreignKey:JurnalID" json:"posting,omitempty"`
+}
+
+func (Jurnal) TableName() string {
+	return "jurnal"
+}
+
+// Posting mendebit atau mengkredit satu akun. Hanya salah satu dari Debit atau Kredit yang diisi.
+type Posting struct {
+	ID         int       `gorm:"column:id;primaryKey" json:"id"`
+	JurnalID   int       `gorm:"column:jurnal_id" json:"jurnal_id"`
+	AkunBukuID int       `gorm:"column:akun_buku_id" json:"akun_buku_id"`
+	Debit      Money     `gorm:"column:debit;type:decimal(15,2)" json:"debit"`
+	Kredit     Money     `gorm:"column:kredit;type:decimal(15,2)" json:"kredit"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (Posting) TableName() string {
+	return "posting"
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"math/big"
+	"time"
+)
+
+const (
+	// MikrosenPerSen adalah satuan akru bunga harian, 1 sen = 1.000.000 mikrosen
+	MikrosenPerSen = 1000000
+	// HariPerTahun adalah pembagi bunga harian (actual/365)
+	HariPerTahun     = 365
+	basisPoinPerSatu = 10000
+)
+
+// SukuBunga adalah satu tier suku bunga tahunan sebuah produk
+type SukuBunga struct {
+	ID              int       `gorm:"column:id;primaryKey" json:"id"`
+	KodeProduk      string    `gorm:"column:kode_produk" json:"kode_produk"`
+	SaldoMinimum    Money     `gorm:"column:saldo_minimum;type:decimal(15,2)" json:"saldo_minimum"`
+	BungaTahunanBps int       `gorm:"column:bunga_tahunan_bps" json:"bunga_tahunan_bps"`
+	BerlakuMulai    time.Time `gorm:"column:berlaku_mulai;type:date" json:"berlaku_mulai"`
+	CreatedAt       time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (SukuBunga) TableName() string {
+	return "suku_bunga"
+}
+
+// PilihSukuBunga mengambil tier dengan saldo minimum tertinggi yang tidak melebihi saldo.
+// tier harus terurut dari saldo minimum terbesar.
+func PilihSukuBunga(tier []SukuBunga, saldo Money) (SukuBunga, bool) {
+	for _, t := range tier {
+		if saldo >= t.SaldoMinimum {
+			return t, true
+		}
+	}
+	return SukuBunga{}, false
+}
+
+// HitungBungaHarian menghitung bunga satu hari dalam mikrosen, dibulatkan ke bawah.
+// Dihitung dengan big.Int agar saldo besar tidak overflow.
+func HitungBungaHarian(saldo Money, bungaTahunanBps int) int64 {
+	if saldo <= 0 || bungaTahunanBps <= 0 {
+		return 0
+	}
+	bunga := new(big.Int).Mul(big.NewInt(int64(saldo)), big.NewInt(int64(bungaTahunanBps)))
+	bunga.Mul(bunga, big.NewInt(MikrosenPerSen))
+	bunga.Quo(bunga, big.NewInt(basisPoinPerSatu*HariPerTahun))
+	return bunga.Int64()
+}
+
+// MikrosenKeMoney membulatkan akru mikrosen ke bawah menjadi sen
+func MikrosenKeMoney(mikrosen int64) Money {
+	return Money(mikrosen / MikrosenPerSen)
+}
+
+// BungaHarian adalah akru bunga satu rekening pada satu tanggal buku
+type BungaHarian struct {
+	Tanggal         time.Time `gorm:"column:tanggal;primaryKey;type:date" json:"tanggal"`
+	RekeningID      int       `gorm:"column:rekening_id;primaryKey" json:"rekening_id"`
+	Saldo           Money     `gorm:"column:saldo;type:decimal(15,2)" json:"saldo"`
+	BungaTahunanBps int       `gorm:"column:bunga_tahunan_bps" json:"bunga_tahunan_bps"`
+	BungaMikrosen   int64     `gorm:"column:bunga_mikrosen" json:"bunga_mikrosen"`
+	Dikreditkan     bool      `gorm:"column:dikreditkan" json:"dikreditkan"`
+	TransaksiID     *int      `gorm:"column:transaksi_id" json:"transaksi_id,omitempty"`
+}
+
+func (BungaHarian) TableName() string {
+	return "bunga_harian"
+}
+
+// AkruBunga menandai tanggal buku yang bunga hariannya sudah selesai diakru
+type AkruBunga struct {
+	Tanggal        time.Time `gorm:"column:tanggal;primaryKey;type:date" json:"tanggal"`
+	JumlahRekening int       `gorm:"column:jumlah_rekening" json:"jumlah_rekening"`
+	CreatedAt      time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (AkruBunga) TableName() string {
+	return "akru_bunga"
+}
+
+// RekapBunga adalah total akru bunga yang belum dikreditkan untuk satu rekening
+type RekapBunga struct {
+	TotalMikrosen int64 `gorm:"column:total_mikrosen"`
+	JumlahHari    int   `gorm:"column:jumlah_hari"`
+}
+
+// HasilBunga adalah ringkasan satu kali proses bunga
+type HasilBunga struct {
+	TanggalDiakru       []string `json:"tanggal_diakru"`
+	PeriodeDikreditkan  []string `json:"periode_dikreditkan"`
+	RekeningDikreditkan int      `json:"rekening_dikreditkan"`
+}
@@ -0,0 +1,84 @@
+package model
+
+import "testing"
+
+func TestPilihSukuBunga(t *testing.T) {
+	tier := []SukuBunga{
+		{SaldoMinimum: Rupiah(10_000_000), BungaTahunanBps: 400},
+		{SaldoMinimum: Rupiah(1_000_000), BungaTahunanBps: 300},
+		{SaldoMinimum: 0, BungaTahunanBps: 200},
+	}
+
+	tests := []struct {
+		nama    string
+		tier    []SukuBunga
+		saldo   Money
+		harapOk bool
+		harap   int
+	}{
+		{"saldo nol masuk tier terendah", tier, 0, true, 200},
+		{"di bawah tier tengah", tier, Rupiah(1_000_000) - 1, true, 200},
+		{"tepat di saldo minimum tier", tier, Rupiah(1_000_000), true, 300},
+		{"di antara dua tier", tier, Rupiah(9_999_999), true, 300},
+		{"di atas tier tertinggi", tier, Rupiah(50_000_000), true, 400},
+		{"saldo negatif tidak dapat tier", tier, -1, false, 0},
+		{"di bawah semua saldo minimum", tier[:2], Rupiah(500_000), false, 0},
+		{"produk tanpa tier", nil, Rupiah(1_000_000), false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			got, ok := PilihSukuBunga(tt.tier, tt.saldo)
+			if ok != tt.harapOk || got.BungaTahunanBps != tt.harap {
+				t.Fatalf("PilihSukuBunga(%s) = %d, %v, seharusnya %d, %v", tt.saldo, got.BungaTahunanBps, ok, tt.harap, tt.harapOk)
+			}
+		})
+	}
+}
+
+func TestHitungBungaHarian(t *testing.T) {
+	tests := []struct {
+		nama  string
+		saldo Money
+		bps   int
+		harap int64
+	}{
+		{"saldo nol", 0, 250, 0},
+		{"saldo negatif", -Rupiah(1_000), 250, 0},
+		{"suku bunga nol", Rupiah(1_000_000), 0, 0},
+		// 1 sen x 0,01% / 365 = 0,27 mikrosen, sisa pembagian big.Int dibuang
+		{"pecahan di bawah satu mikrosen", 1, 1, 0},
+		// 100.000.000 sen x 2,5% / 365 = 6.849.315.068,49 mikrosen
+		{"sisa pembagian dibulatkan ke bawah", Rupiah(1_000_000), 250, 6_849_315_068},
+		// perkalian antara 5e23 melewati int64, hanya hasil akhirnya yang muat
+		{"saldo besar tidak overflow", Money(1_000_000_000_000_000), 500, 136_986_301_369_863_013},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := HitungBungaHarian(tt.saldo, tt.bps); got != tt.harap {
+				t.Fatalf("HitungBungaHarian(%s, %d) = %d, seharusnya %d", tt.saldo, tt.bps, got, tt.harap)
+			}
+		})
+	}
+}
+
+func TestMikrosenKeMoney(t *testing.T) {
+	tests := []struct {
+		nama     string
+		mikrosen int64
+		harap    Money
+	}{
+		{"nol", 0, 0},
+		{"kurang dari satu sen", MikrosenPerSen - 1, 0},
+		{"tepat satu sen", MikrosenPerSen, 1},
+		{"sisa pecahan dibuang", 2*MikrosenPerSen - 1, 1},
+		// akru 30 hari dari 6.849.315.068 mikrosen per hari
+		{"akru sebulan", 30 * 6_849_315_068, 205_479},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			if got := MikrosenKeMoney(tt.mikrosen); got != tt.harap {
+				t.Fatalf("MikrosenKeMoney(%d) = %d, seharusnya %d", tt.mikrosen, got, tt.harap)
+			}
+		})
+	}
+}
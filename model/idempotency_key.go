@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// IdempotencyKey mencatat hasil pertama sebuah request yang membawa header Idempotency-Key.
+// StatusCode 0 berarti request pertama masih diproses.
+type IdempotencyKey struct {
+	ID           int       `gorm:"column:id;primaryKey" json:"id"`
+	Kunci        string    `gorm:"column:kunci" json:"kunci"`
+	Endpoint     string    `gorm:"column:endpoint" json:"endpoint"`
+	RequestHash  string    `gorm:"column:request_hash" json:"request_hash"`
+	StatusCode   int       `gorm:"column:status_code" json:"status_code"`
+	ResponseBody string    `gorm:"column:response_body" json:"response_body"`
+	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_key"
+}
@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+const (
+	KYCUnverified = "unverified"
+	KYCPending    = "pending"
+	KYCVerified   = "verified"
+	KYCRejected   = "rejected"
+)
+
+// Rentang penghasilan per bulan yang bisa dipilih nasabah
+const (
+	PenghasilanSampai5Jt  = "0-5jt"
+	Penghasilan5Sd10Jt    = "5-10jt"
+	Penghasilan10Sd25Jt   = "10-25jt"
+	Penghasilan25Sd50Jt   = "25-50jt"
+	PenghasilanDiatas50Jt = ">50jt"
+)
+
+// IsRentangPenghasilan memeriksa apakah rentang penghasilan termasuk pilihan yang dikenal
+func IsRentangPenghasilan(rentang string) bool {
+	switch rentang {
+	case PenghasilanSampai5Jt, Penghasilan5Sd10Jt, Penghasilan10Sd25Jt, Penghasilan25Sd50Jt, PenghasilanDiatas50Jt:
+		return true
+	}
+	return false
+}
+
+// KYC adalah data Know Your Customer yang disimpan di tabel nasabah
+type KYC struct {
+	TanggalLahir       *time.Time `gorm:"column:tanggal_lahir;type:date" json:"tanggal_lahir,omitempty"`
+	TempatLahir        string     `gorm:"column:tempat_lahir" json:"tempat_lahir,omitempty"`
+	Alamat             string     `gorm:"column:alamat" json:"alamat,omitempty"`
+	NamaIbuKandung     string     `gorm:"column:nama_ibu_kandung" json:"nama_ibu_kandung,omitempty"`
+	Pekerjaan          string     `gorm:"column:pekerjaan" json:"pekerjaan,omitempty"`
+	RentangPenghasilan string     `gorm:"column:rentang_penghasilan" json:"rentang_penghasilan,omitempty"`
+	Email              string     `gorm:"column:email" json:"email,omitempty"`
+	NoDokumen          string     `gorm:"column:no_dokumen" json:"no_dokumen,omitempty"`
+	Status             string     `gorm:"column:kyc_status;default:unverified" json:"status"`
+	Catatan            string     `gorm:"column:kyc_catatan" json:"catatan,omitempty"`
+	DiverifikasiOleh   string     `gorm:"column:kyc_diverifikasi_oleh" json:"diverifikasi_oleh,omitempty"`
+	DiverifikasiAt     *time.Time `gorm:"column:kyc_diverifikasi_at" json:"diverifikasi_at,omitempty"`
+}
+
+// AjukanKYC adalah data KYC yang dikirim nasabah. TanggalLahir berformat YYYY-MM-DD
+// dan harus sama dengan tanggal lahir yang tertera di NIK.
+type AjukanKYC struct {
+	TanggalLahir       string `json:"tanggal_lahir"`
+	TempatLahir        string `json:"tempat_lahir"`
+	Alamat             string `json:"alamat"`
+	NamaIbuKandung     string `json:"nama_ibu_kandung"`
+	Pekerjaan          string `json:"pekerjaan"`
+	RentangPenghasilan string `json:"rentang_penghasilan"`
+	Email              string `json:"email"`
+	NoDokumen          string `json:"no_dokumen"`
+}
+
+type VerifikasiKYC struct {
+	Catatan string `json:"catatan"`
+}
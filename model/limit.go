@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+const (
+	ArahDebit  = "debit"
+	ArahKredit = "kredit"
+)
+
+// jenisPerArah menentukan jenis transaksi yang dihitung ke pemakaian harian tiap arah.
+// Transfer masuk sengaja tidak dihitung sebagai kredit agar transfer tidak gagal
+// karena batas milik penerima.
+var jenisPerArah = map[string][]string{
+	ArahDebit:  {JenisTarik, JenisTransferKeluar},
+	ArahKredit: {JenisTabung},
+}
+
+// JenisTransaksiArah mengembalikan jenis transaksi yang dihitung untuk arah debit atau kredit
+func JenisTransaksiArah(arah string) []string {
+	return jenisPerArah[arah]
+}
+
+// LimitTransaksi adalah satu aturan batas transaksi. KodeProduk atau KYCStatus yang nil
+// berarti aturan berlaku untuk semua, dan batas yang nil berarti tidak dibatasi.
+type LimitTransaksi struct {
+	ID               int       `gorm:"column:id;primaryKey" json:"id"`
+	KodeProduk       *string   `gorm:"column:kode_produk" json:"kode_produk"`
+	KYCStatus        *string   `gorm:"column:kyc_status" json:"kyc_status"`
+	Arah             string    `gorm:"column:arah" json:"arah"`
+	MaksPerTransaksi *Money    `gorm:"column:maks_per_transaksi;type:decimal(15,2)" json:"maks_per_transaksi"`
+	MaksHarian       *Money    `gorm:"column:maks_harian;type:decimal(15,2)" json:"maks_harian"`
+	MaksJumlahHarian *int      `gorm:"column:maks_jumlah_harian" json:"maks_jumlah_harian"`
+	CreatedAt        time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (LimitTransaksi) TableName() string {
+	return "limit_transaksi"
+}
+
+// RekapHarian adalah total nominal dan jumlah transaksi sebuah rekening dalam satu hari
+type RekapHarian struct {
+	Total  Money `gorm:"column:total"`
+	Jumlah int   `gorm:"column:jumlah"`
+}
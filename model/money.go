@@ -0,0 +1,140 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money menyimpan nominal uang dalam satuan sen (1/100 rupiah) sebagai bilangan bulat,
+// sehingga penjumlahan dan pengurangan saldo selalu eksak tanpa galat pembulatan float.
+// Di JSON dan database nilainya tetap ditulis sebagai desimal dengan 2 angka di belakang koma.
+type Money int64
+
+const centsPerRupiah = 100
+
+var ErrInvalidMoney = errors.New("format nominal tidak valid")
+
+// Rupiah membuat Money dari nominal rupiah utuh
+func Rupiah(rp int64) Money {
+	return Money(rp * centsPerRupiah)
+}
+
+// ParseMoney mengubah string desimal seperti "150000", "150000.5" atau "-2500.75" menjadi Money.
+// Lebih dari 2 angka di belakang koma dianggap tidak valid karena tidak bisa direpresentasikan eksak.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ErrInvalidMoney
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, hasFrac := strings.Cut(s, ".")
+	if !isDigits(whole) || (hasFrac && !isDigits(frac)) || len(frac) > 2 {
+		return 0, ErrInvalidMoney
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+
+	sen, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidMoney
+	}
+	rp, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || rp > (math.MaxInt64-sen)/centsPerRupiah {
+		return 0, ErrInvalidMoney
+	}
+
+	m := Money(rp*centsPerRupiah + sen)
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+// IsWhole bernilai true jika nominal tidak memiliki pecahan sen
+func (m Money) IsWhole() bool {
+	return m%centsPerRupiah == 0
+}
+
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/centsPerRupiah, v%centsPerRupiah)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON menerima angka JSON (150000.50) maupun string ("150000.50")
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		return nil
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+func (m *Money) Scan(src interface{}) error {
+	var (
+		parsed Money
+		err    error
+	)
+	switch v := src.(type) {
+	case nil:
+		parsed = 0
+	case string:
+		parsed, err = ParseMoney(v)
+	case []byte:
+		parsed, err = ParseMoney(string(v))
+	case int64:
+		parsed = Rupiah(v)
+	case float64:
+		parsed, err = ParseMoney(strconv.FormatFloat(v, 'f', 2, 64))
+	default:
+		err = fmt.Errorf("tidak bisa membaca %T sebagai Money", src)
+	}
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Money
+		wantErr bool
+	}{
+		{name: "rupiah utuh", input: "150000", want: Rupiah(150000)},
+		{name: "satu angka sen", input: "150000.5", want: Money(15000050)},
+		{name: "dua angka sen", input: "2500.75", want: Money(250075)},
+		{name: "negatif", input: "-2500.75", want: Money(-250075)},
+		{name: "tanda plus", input: "+10", want: Rupiah(10)},
+		{name: "spasi di tepi", input: " 10.00 ", want: Rupiah(10)},
+		{name: "batas atas tepat", input: "92233720368547758.07", want: Money(math.MaxInt64)},
+		{name: "batas atas negatif", input: "-92233720368547758.07", want: Money(-math.MaxInt64)},
+		{name: "rupiah di batas, sen meluap", input: "92233720368547758.08", wantErr: true},
+		{name: "rupiah di batas, sen maksimum", input: "92233720368547758.99", wantErr: true},
+		{name: "rupiah melewati batas", input: "92233720368547759", wantErr: true},
+		{name: "tiga angka sen", input: "1.001", wantErr: true},
+		{name: "kosong", input: "", wantErr: true},
+		{name: "bukan angka", input: "1e5", wantErr: true},
+		{name: "hanya titik", input: ".50", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMoney) {
+					t.Fatalf("ParseMoney(%q) = %d, %v; seharusnya ErrInvalidMoney", tt.input, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMoney(%q) error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Fatalf("ParseMoney(%q) = %d, seharusnya %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// MutasiFilter adalah parameter pencarian riwayat transaksi sebuah rekening.
+// Dari bersifat inklusif sedangkan Sampai eksklusif.
+type MutasiFilter struct {
+	NoRekening     string
+	RekeningID     int
+	Dari           *time.Time
+	Sampai         *time.Time
+	JenisTransaksi string
+	Sort           string
+	Page           int
+	Limit          int
+}
+
+type Mutasi struct {
+	NoRekening string      `json:"no_rekening"`
+	Page       int         `json:"page"`
+	Limit      int         `json:"limit"`
+	Total      int64       `json:"total"`
+	Transaksi  []Transaksi `json:"transaksi"`
+}
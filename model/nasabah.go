@@ -5,12 +5,19 @@ import (
 )
 
 type Nasabah struct {
-	ID        int       `gorm:"column:id;primaryKey" json:"id"`
-	Nama      string    `gorm:"column:nama" json:"nama"`
-	NIK       string    `gorm:"column:nik" json:"nik"`
-	NoHP      string    `gorm:"column:no_hp" json:"no_hp"`
-	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
-	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+	ID            int        `gorm:"column:id;primaryKey" json:"id"`
+	Nama          string     `gorm:"column:nama" json:"nama"`
+	NIK           string     `gorm:"column:nik" json:"nik"`
+	NoHP          string     `gorm:"column:no_hp" json:"no_hp"`
+	Password      string     `gorm:"-" json:"password,omitempty"`
+	PasswordHash  string     `gorm:"column:password_hash" json:"-"`
+	Pin           string     `gorm:"-" json:"pin,omitempty"`
+	PinHash       string     `gorm:"column:pin_hash" json:"-"`
+	PinGagal      int        `gorm:"column:pin_gagal" json:"-"`
+	PinTerkunciAt *time.Time `gorm:"column:pin_terkunci_at" json:"-"`
+	KYC           KYC        `gorm:"embedded" json:"kyc"`
+	CreatedAt     time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"column:updated_at" json:"updated_at"`
 }
 
 func (Nasabah) TableName() string {
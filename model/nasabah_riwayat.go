@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// NasabahRiwayat mencatat satu perubahan field profil nasabah beserta pelakunya
+type NasabahRiwayat struct {
+	ID         int       `gorm:"column:id;primaryKey" json:"id"`
+	NasabahID  int       `gorm:"column:nasabah_id" json:"nasabah_id"`
+	Field      string    `gorm:"column:field" json:"field"`
+	NilaiLama  string    `gorm:"column:nilai_lama" json:"nilai_lama"`
+	NilaiBaru  string    `gorm:"column:nilai_baru" json:"nilai_baru"`
+	DiubahOleh string    `gorm:"column:diubah_oleh" json:"diubah_oleh"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (NasabahRiwayat) TableName() string {
+	return "nasabah_riwayat"
+}
+
+// UbahProfil berisi field profil yang ingin diubah. Field nil berarti tidak diubah.
+type UbahProfil struct {
+	Nama *string `json:"nama"`
+	NoHP *string `json:"no_hp"`
+}
@@ -0,0 +1,12 @@
+package model
+
+type UbahPin struct {
+	PinLama string `json:"pin_lama"`
+	PinBaru string `json:"pin_baru"`
+}
+
+// ResetPin dipakai saat PIN lupa atau terkunci, diverifikasi dengan password login
+type ResetPin struct {
+	Password string `json:"password"`
+	PinBaru  string `json:"pin_baru"`
+}
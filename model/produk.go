@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+const (
+	ProdukTabungan = "tabungan"
+	ProdukDeposito = "deposito"
+)
+
+// Produk menyimpan aturan yang berlaku untuk semua rekening dengan kode produk tersebut
+type Produk struct {
+	Kode         string    `gorm:"column:kode;primaryKey" json:"kode"`
+	Nama         string    `gorm:"column:nama" json:"nama"`
+	SaldoMinimum Money     `gorm:"column:saldo_minimum;type:decimal(15,2)" json:"saldo_minimum"`
+	BolehTarik   bool      `gorm:"column:boleh_tarik" json:"boleh_tarik"`
+	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"column:updated_at" json:"updated_at"`
+
+	// Biaya produk. Penarikan dikenai BiayaTarik setelah GratisTarikPerBulan penarikan dalam
+	// bulan kalender yang sama, KebijakanBiaya berlaku jika saldo tidak cukup membayar biaya.
+	BiayaAdminBulanan   Money  `gorm:"column:biaya_admin_bulanan;type:decimal(15,2)" json:"biaya_admin_bulanan"`
+	BiayaTarik          Money  `gorm:"column:biaya_tarik;type:decimal(15,2)" json:"biaya_tarik"`
+	GratisTarikPerBulan int    `gorm:"column:gratis_tarik_per_bulan" json:"gratis_tarik_per_bulan"`
+	BiayaTransfer       Money  `gorm:"column:biaya_transfer;type:decimal(15,2)" json:"biaya_transfer"`
+	KebijakanBiaya      string `gorm:"column:kebijakan_biaya" json:"kebijakan_biaya"`
+}
+
+func (Produk) TableName() string {
+	return "produk"
+}
+
+type BukaRekening struct {
+	KodeProduk string `json:"kode_produk"`
+}
@@ -2,11 +2,47 @@ package model
 
 import "time"
 
+const (
+	StatusAktif     = "aktif"
+	StatusDormant   = "dormant"
+	StatusDibekukan = "dibekukan"
+	StatusDitutup   = "ditutup"
+)
+
+// statusTransitions berisi perpindahan status rekening yang diizinkan.
+// Rekening yang sudah ditutup tidak bisa dibuka kembali.
+var statusTransitions = map[string][]string{
+	StatusAktif:     {StatusDormant, StatusDibekukan, StatusDitutup},
+	StatusDormant:   {StatusAktif, StatusDibekukan, StatusDitutup},
+	StatusDibekukan: {StatusAktif, StatusDitutup},
+}
+
+// CanTransition memeriksa apakah status rekening boleh berpindah dari "from" ke "to"
+func CanTransition(from, to string) bool {
+	for _, allowed := range statusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStatusRekening memeriksa apakah status termasuk status rekening yang dikenal
+func IsStatusRekening(status string) bool {
+	switch status {
+	case StatusAktif, StatusDormant, StatusDibekukan, StatusDitutup:
+		return true
+	}
+	return false
+}
+
 type Rekening struct {
 	ID         int       `gorm:"column:id;primaryKey" json:"id"`
 	NasabahID  int       `gorm:"column:nasabah_id" json:"nasabah_id"`
 	NoRekening string    `gorm:"column:no_rekening" json:"no_rekening"`
-	Saldo      float64   `gorm:"column:saldo" json:"saldo"`
+	KodeProduk string    `gorm:"column:kode_produk" json:"kode_produk"`
+	Saldo      Money     `gorm:"column:saldo;type:decimal(15,2)" json:"saldo"`
+	Status     string    `gorm:"column:status;default:aktif" json:"status"`
 	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt  time.Time `gorm:"column:updated_at" json:"updated_at"`
 
@@ -17,3 +53,10 @@ type Rekening struct {
 func (Rekening) TableName() string {
 	return "rekening"
 }
+
+type UbahStatusRekening struct {
+	Status string `json:"status"`
+	Alasan string `json:"alasan"`
+	// PembayaranAkhir mencairkan seluruh saldo sebelum rekening ditutup
+	PembayaranAkhir bool `json:"pembayaran_akhir"`
+}
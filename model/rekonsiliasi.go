@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+// Pemicu job batch seperti rekonsiliasi dan tutup hari
+const (
+	PemicuCLI    = "cli"
+	PemicuJadwal = "jadwal"
+	PemicuAdmin  = "admin"
+)
+
+const (
+	StatusRekonsiliasiBerjalan = "berjalan"
+	StatusRekonsiliasiSelesai  = "selesai"
+	StatusRekonsiliasiGagal    = "gagal"
+)
+
+// Rekonsiliasi adalah satu kali jalannya job rekonsiliasi saldo. Run yang berhenti karena
+// error tetap ditutup dengan status gagal, jadi status berjalan berarti job masih bekerja.
+type Rekonsiliasi struct {
+	ID             int                   `gorm:"column:id;primaryKey" json:"id"`
+	Pemicu         string                `gorm:"column:pemicu" json:"pemicu"`
+	Status         string                `gorm:"column:status" json:"status"`
+	Bekukan        bool                  `gorm:"column:bekukan" json:"bekukan"`
+	JumlahRekening int                   `gorm:"column:jumlah_rekening" json:"jumlah_rekening"`
+	JumlahSelisih  int                   `gorm:"column:jumlah_selisih" json:"jumlah_selisih"`
+	MulaiAt        time.Time             `gorm:"column:mulai_at" json:"mulai_at"`
+	SelesaiAt      *time.Time            `gorm:"column:selesai_at" json:"selesai_at"`
+	PesanGagal     string                `gorm:"column:pesan_gagal" json:"pesan_gagal,omitempty"`
+	Selisih        []RekonsiliasiSelisih `gorm:"foreignKey:RekonsiliasiID" json:"selisih,omitempty"`
+}
+
+func (Rekonsiliasi) TableName() string {
+	return "rekonsiliasi"
+}
+
+// RekonsiliasiSelisih adalah rekening yang saldonya tidak cocok saat rekonsiliasi
+type RekonsiliasiSelisih struct {
+	ID             int       `gorm:"column:id;primaryKey" json:"id"`
+	RekonsiliasiID int       `gorm:"column:rekonsiliasi_id" json:"rekonsiliasi_id"`
+	RekeningID     int       `gorm:"column:rekening_id" json:"rekening_id"`
+	NoRekening     string    `gorm:"column:no_rekening" json:"no_rekening"`
+	SaldoRekening  Money     `gorm:"column:saldo_rekening;type:decimal(15,2)" json:"saldo_rekening"`
+	SaldoTransaksi Money     `gorm:"column:saldo_transaksi;type:decimal(15,2)" json:"saldo_transaksi"`
+	SaldoBukuBesar Money     `gorm:"column:saldo_buku_besar;type:decimal(15,2)" json:"saldo_buku_besar"`
+	Dibekukan      bool      `gorm:"column:dibekukan" json:"dibekukan"`
+	CreatedAt      time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (RekonsiliasiSelisih) TableName() string {
+	return "rekonsiliasi_selisih"
+}
+
+type JalankanRekonsiliasi struct {
+	Bekukan bool `json:"bekukan"`
+}
@@ -0,0 +1,27 @@
+package model
+
+// Kode alasan koreksi transaksi
+const (
+	AlasanSalahNominal      = "SALAH_NOMINAL"
+	AlasanSalahRekening     = "SALAH_REKENING"
+	AlasanDuplikat          = "DUPLIKAT"
+	AlasanPermintaanNasabah = "PERMINTAAN_NASABAH"
+	AlasanLainnya           = "LAINNYA"
+)
+
+// IsKodeAlasan memeriksa apakah kode alasan koreksi dikenal
+func IsKodeAlasan(kode string) bool {
+	switch kode {
+	case AlasanSalahNominal, AlasanSalahRekening, AlasanDuplikat, AlasanPermintaanNasabah, AlasanLainnya:
+		return true
+	}
+	return false
+}
+
+// Reversal adalah permintaan koreksi sebuah transaksi. Override mengizinkan koreksi
+// yang membuat saldo rekening menjadi negatif.
+type Reversal struct {
+	KodeAlasan string `json:"kode_alasan"`
+	Keterangan string `json:"keterangan"`
+	Override   bool   `json:"override"`
+}
@@ -2,13 +2,72 @@ package model
 
 import "time"
 
+const (
+	JenisTabung         = "tabung"
+	JenisTarik          = "tarik"
+	JenisTransferKeluar = "transfer_keluar"
+	JenisTransferMasuk  = "transfer_masuk"
+	// JenisKoreksiDebit mengurangi saldo untuk membatalkan transaksi kredit,
+	// JenisKoreksiKredit menambah saldo untuk membatalkan transaksi debit
+	JenisKoreksiDebit  = "koreksi_debit"
+	JenisKoreksiKredit = "koreksi_kredit"
+	// JenisBunga adalah bunga bulanan yang dikreditkan, JenisPajak adalah pajak atas bunga tersebut
+	JenisBunga = "bunga"
+	JenisPajak = "pajak"
+	// JenisBiaya adalah biaya admin, tarik atau transfer yang dibebankan ke rekening
+	JenisBiaya = "biaya"
+)
+
+// IsJenisTransaksi memeriksa apakah jenis termasuk nilai enum jenis_transaksi
+func IsJenisTransaksi(jenis string) bool {
+	switch jenis {
+	case JenisTabung, JenisTarik, JenisTransferKeluar, JenisTransferMasuk, JenisKoreksiDebit, JenisKoreksiKredit, JenisBunga, JenisPajak, JenisBiaya:
+		return true
+	}
+	return false
+}
+
+// jenisDebit berisi jenis transaksi yang mengurangi saldo, jenis lain menambah saldo
+var jenisDebit = []string{JenisTarik, JenisTransferKeluar, JenisKoreksiDebit, JenisPajak, JenisBiaya}
+
+// JenisDebit mengembalikan salinan daftar jenis transaksi yang mengurangi saldo
+func JenisDebit() []string {
+	return append([]string(nil), jenisDebit...)
+}
+
+// IsDebit bernilai true untuk jenis transaksi yang mengurangi saldo
+func IsDebit(jenis string) bool {
+	for _, debit := range jenisDebit {
+		if jenis == debit {
+			return true
+		}
+	}
+	return false
+}
+
 type Transaksi struct {
-	ID             int       `gorm:"column:id;primaryKey" json:"id"`
-	RekeningID     int       `gorm:"column:rekening_id" json:"rekening_id"`
-	Nominal        float64   `gorm:"column:nominal" json:"nominal"`
-	JenisTransaksi string    `gorm:"column:jenis_transaksi" json:"jenis_transaksi"`
-	CreatedAt      time.Time `gorm:"column:created_at" json:"created_at"`
-	UpdatedAt      time.Time `gorm:"column:updated_at" json:"updated_at"`
+	ID              int       `gorm:"column:id;primaryKey" json:"id"`
+	RekeningID      int       `gorm:"column:rekening_id" json:"rekening_id"`
+	Nominal         Money     `gorm:"column:nominal;type:decimal(15,2)" json:"nominal"`
+	JenisTransaksi  string    `gorm:"column:jenis_transaksi" json:"jenis_transaksi"`
+	SaldoAwal       Money     `gorm:"column:saldo_awal;type:decimal(15,2)" json:"saldo_awal"`
+	SaldoAkhir      Money     `gorm:"column:saldo_akhir;type:decimal(15,2)" json:"saldo_akhir"`
+	NoReferensi     string    `gorm:"column:no_referensi;default:null" json:"no_referensi,omitempty"`
+	JurnalID        *int      `gorm:"column:jurnal_id" json:"jurnal_id,omitempty"`
+	TransaksiAsalID *int      `gorm:"column:transaksi_asal_id" json:"transaksi_asal_id,omitempty"`
+	KodeAlasan      string    `gorm:"column:kode_alasan;default:null" json:"kode_alasan,omitempty"`
+	Operator        string    `gorm:"column:operator;default:null" json:"operator,omitempty"`
+	Pin             string    `gorm:"-" json:"pin,omitempty"`
+	CreatedAt       time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt       time.Time `gorm:"column:updated_at" json:"updated_at"`
+
+	// diisi pada transaksi biaya: transaksi yang memicu biaya dan tagihan yang dibayar
+	TransaksiPemicuID *int `gorm:"column:transaksi_pemicu_id" json:"transaksi_pemicu_id,omitempty"`
+	TagihanBiayaID    *int `gorm:"column:tagihan_biaya_id" json:"tagihan_biaya_id,omitempty"`
+
+	// diisi pada baris utama tabung, tarik dan transfer yang dikirim dengan Idempotency-Key,
+	// sehingga retry bisa menemukan transaksi yang sudah ter-commit alih-alih mengulangnya
+	IdempotencyKeyID *int `gorm:"column:idempotency_key_id" json:"-"`
 
 	Rekening Rekening `gorm:"foreignKey:RekeningID;references:ID" json:"rekening"`
 }
@@ -16,3 +75,12 @@ type Transaksi struct {
 func (Transaksi) TableName() string {
 	return "transaksi"
 }
+
+// HasilTransaksi adalah transaksi utama yang dicatat beserta biaya yang langsung tertagih
+// karenanya dan saldo rekening setelah biaya tersebut. Baris transaksinya sendiri tetap
+// mencatat saldo sebelum biaya.
+type HasilTransaksi struct {
+	Transaksi Transaksi
+	Biaya     Money
+	Saldo     Money
+}
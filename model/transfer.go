@@ -0,0 +1,10 @@
+package model
+
+// Transfer adalah permintaan pemindahan dana antar rekening
+type Transfer struct {
+	NoRekeningAsal   string `json:"no_rekening_asal"`
+	NoRekeningTujuan string `json:"no_rekening_tujuan"`
+	Nominal          Money  `json:"nominal"`
+	Pin              string `json:"pin"`
+	IdempotencyKeyID *int   `json:"-"`
+}
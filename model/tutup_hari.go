@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+const (
+	StatusTutupHariBerjalan = "berjalan"
+	StatusTutupHariSelesai  = "selesai"
+)
+
+// TutupHari adalah proses akhir hari untuk satu tanggal buku. Selama statusnya berjalan,
+// proses bisa diulang dan akan melanjutkan snapshot rekening yang belum tercatat.
+type TutupHari struct {
+	Tanggal        time.Time        `gorm:"column:tanggal;primaryKey;type:date" json:"tanggal"`
+	Status         string           `gorm:"column:status" json:"status"`
+	Pemicu         string           `gorm:"column:pemicu" json:"pemicu"`
+	JumlahRekening int              `gorm:"column:jumlah_rekening" json:"jumlah_rekening"`
+	MulaiAt        time.Time        `gorm:"column:mulai_at" json:"mulai_at"`
+	SelesaiAt      *time.Time       `gorm:"column:selesai_at" json:"selesai_at"`
+	Total          []TutupHariTotal `gorm:"foreignKey:Tanggal;references:Tanggal" json:"total,omitempty"`
+}
+
+func (TutupHari) TableName() string {
+	return "tutup_hari"
+}
+
+// TutupHariTotal adalah total transaksi semua rekening untuk satu jenis pada satu tanggal buku
+type TutupHariTotal struct {
+	Tanggal         time.Time `gorm:"column:tanggal;primaryKey;type:date" json:"-"`
+	JenisTransaksi  string    `gorm:"column:jenis_transaksi;primaryKey" json:"jenis_transaksi"`
+	JumlahTransaksi int       `gorm:"column:jumlah_transaksi" json:"jumlah_transaksi"`
+	TotalNominal    Money     `gorm:"column:total_nominal;type:decimal(15,2)" json:"total_nominal"`
+}
+
+func (TutupHariTotal) TableName() string {
+	return "tutup_hari_total"
+}
+
+// SaldoHarian adalah saldo penutupan satu rekening pada satu tanggal buku beserta
+// ringkasan mutasinya di tanggal tersebut
+type SaldoHarian struct {
+	Tanggal         time.Time `gorm:"column:tanggal;primaryKey;type:date" json:"tanggal"`
+	RekeningID      int       `gorm:"column:rekening_id;primaryKey" json:"rekening_id"`
+	NoRekening      string    `gorm:"column:no_rekening" json:"no_rekening"`
+	KodeProduk      string    `gorm:"column:kode_produk" json:"kode_produk"`
+	SaldoAkhir      Money     `gorm:"column:saldo_akhir;type:decimal(15,2)" json:"saldo_akhir"`
+	TotalDebit      Money     `gorm:"column:total_debit;type:decimal(15,2)" json:"total_debit"`
+	TotalKredit     Money     `gorm:"column:total_kredit;type:decimal(15,2)" json:"total_kredit"`
+	JumlahTransaksi int       `gorm:"column:jumlah_transaksi" json:"jumlah_transaksi"`
+	CreatedAt       time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (SaldoHarian) TableName() string {
+	return "saldo_harian"
+}
+
+// JalankanTutupHari adalah permintaan tutup hari. Tanggal berformat YYYY-MM-DD,
+// kosong berarti tanggal berikutnya yang belum ditutup
+type JalankanTutupHari struct {
+	Tanggal string `json:"tanggal"`
+}
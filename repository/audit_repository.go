@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type AuditRepository interface {
+	Create(newEvent model.AuditEvent) (model.AuditEvent, error)
+	FindSetelah(rantai string, afterID int, limit int) ([]model.AuditEvent, error)
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// Create menambahkan entri di ujung rantai newEvent.Rantai. Advisory lock per rantai ditahan
+// sampai transaksi database selesai sehingga entri dari transaksi paralel di rantai yang sama
+// tidak bisa menunjuk prev_hash yang sama, dan urutan id di satu rantai selalu sama dengan
+// urutan rantainya. Rantai lain tidak ikut menunggu.
+func (r *auditRepository) Create(newEvent model.AuditEvent) (model.AuditEvent, error) {
+	if err := r.db.Exec("SELECT pg_advisory_xact_lock(hashtextextended(?, 0))", newEvent.Rantai).Error; err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"rantai": newEvent.Rantai,
+			"action": "create audit event",
+			"layer":  "repository",
+		}).Error("Gagal mengunci rantai audit")
+		return model.AuditEvent{}, err
+	}
+
+	var last model.AuditEvent
+	err := r.db.Where("rantai = ?", newEvent.Rantai).Order("id DESC").First(&last).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		newEvent.PrevHash = model.GenesisHash
+	case err != nil:
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"action": "create audit event",
+			"layer":  "repository",
+		}).Error("Gagal membaca ujung rantai audit")
+		return model.AuditEvent{}, err
+	default:
+		newEvent.PrevHash = last.Hash
+	}
+	newEvent.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	newEvent.Hash = newEvent.ComputeHash()
+
+	result := r.db.Create(&newEvent)
+	if result.Error != nil {
+		utils.Log.WithError(result.Error).WithFields(logrus.Fields{
+			"nasabah_id": newEvent.NasabahID,
+			"jenis":      newEvent.Jenis,
+			"action":     "create audit event",
+			"layer":      "repository",
+		}).Error("Gagal mencatat audit event")
+		return model.AuditEvent{}, result.Error
+	}
+	utils.Log.WithFields(logrus.Fields{
+		"id":         newEvent.ID,
+		"nasabah_id": newEvent.NasabahID,
+		"jenis":      newEvent.Jenis,
+		"keterangan": newEvent.Keterangan,
+		"action":     "create audit event",
+		"layer":      "repository",
+	}).Info("Audit event tercatat")
+	return newEvent, nil
+}
+
+// FindSetelah membaca semua rantai audit berurutan per rantai lalu per id, dimulai setelah
+// entri (rantai, afterID), untuk verifikasi per halaman
+func (r *auditRepository) FindSetelah(rantai string, afterID int, limit int) ([]model.AuditEvent, error) {
+	var events []model.AuditEvent
+	err := r.db.Where("(rantai, id) > (?, ?)", rantai, afterID).Order("rantai ASC, id ASC").Limit(limit).Find(&events).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"rantai":   rantai,
+			"after_id": afterID,
+			"action":   "FindSetelah",
+			"layer":    "repository",
+		}).Error("Gagal membaca rantai audit")
+		return nil, err
+	}
+	return events, nil
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db}
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type BiayaRepository interface {
+	CreateTagihan(tagihan model.TagihanBiaya) (model.TagihanBiaya, error)
+	UpdateTagihan(tagihan model.TagihanBiaya) error
+	FindTagihanAdmin(rekeningID int, periode time.Time) (model.TagihanBiaya, error)
+	FindTunggakan(rekeningID int) ([]model.TagihanBiaya, error)
+	FindTagihanByID(id int) (model.TagihanBiaya, error)
+	FindTagihanByPemicu(transaksiPemicuID int) (model.TagihanBiaya, error)
+}
+
+type biayaRepository struct {
+	db *gorm.DB
+}
+
+func (r *biayaRepository) CreateTagihan(tagihan model.TagihanBiaya) (model.TagihanBiaya, error) {
+	if err := r.db.Create(&tagihan).Error; err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"rekening_id": tagihan.RekeningID,
+			"jenis_biaya": tagihan.JenisBiaya,
+			"action":      "CreateTagihan",
+			"layer":       "repository",
+		}).Error("Gagal mencatat tagihan biaya")
+		return model.TagihanBiaya{}, translateError(err)
+	}
+	return tagihan, nil
+}
+
+// UpdateTagihan menyimpan jumlah tertagih dan status tagihan
+func (r *biayaRepository) UpdateTagihan(tagihan model.TagihanBiaya) error {
+	err := r.db.Model(&model.TagihanBiaya{ID: tagihan.ID}).Updates(map[string]interface{}{
+		"tertagih": tagihan.Tertagih,
+		"status":   tagihan.Status,
+	}).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"id":     tagihan.ID,
+			"action": "UpdateTagihan",
+			"layer":  "repository",
+		}).Error("Gagal mengubah tagihan biaya")
+	}
+	return err
+}
+
+func (r *biayaRepository) FindTagihanAdmin(rekeningID int, periode time.Time) (model.TagihanBiaya, error) {
+	var tagihan model.TagihanBiaya
+	err := r.db.Where("rekening_id = ? AND jenis_biaya = ? AND periode = ?", rekeningID, model.BiayaAdminBulanan, periode.Format(formatTanggal)).
+		First(&tagihan).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.TagihanBiaya{}, nil
+	}
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"rekening_id": rekeningID,
+			"periode":     periode.Format(formatTanggal),
+			"action":      "FindTagihanAdmin",
+			"layer":       "repository",
+		}).Error("Gagal mencari tagihan biaya admin")
+		return model.TagihanBiaya{}, err
+	}
+	return tagihan, nil
+}
+
+// FindTunggakan mengambil tagihan rekening yang masih menunggak, dari yang paling lama
+func (r *biayaRepository) FindTunggakan(rekeningID int) ([]model.TagihanBiaya, error) {
+	var tagihan []model.TagihanBiaya
+	err := r.db.Where("rekening_id = ? AND status = ?", rekeningID, model.StatusTagihanTunggakan).
+		Order("id ASC").
+		Find(&tagihan).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"rekening_id": rekeningID,
+			"action":      "FindTunggakan",
+			"layer":       "repository",
+		}).Error("Gagal mengambil tunggakan biaya")
+		return nil, err
+	}
+	return tagihan, nil
+}
+
+func (r *biayaRepository) FindTagihanByID(id int) (model.TagihanBiaya, error) {
+	var tagihan model.TagihanBiaya
+	err := r.db.Where("id = ?", id).First(&tagihan).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.TagihanBiaya{}, nil
+	}
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"id":     id,
+			"action": "FindTagihanByID",
+			"layer":  "repository",
+		}).Error("Gagal mencari tagihan biaya")
+		return model.TagihanBiaya{}, err
+	}
+	return tagihan, nil
+}
+
+// FindTagihanByPemicu mengambil tagihan biaya tarik atau transfer dari transaksi pemicunya
+func (r *biayaRepository) FindTagihanByPemicu(transaksiPemicuID int) (model.TagihanBiaya, error) {
+	var tagihan model.TagihanBiaya
+	err := r.db.Where("transaksi_pemicu_id = ?", transaksiPemicuID).First(&tagihan).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.TagihanBiaya{}, nil
+	}
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"transaksi_pemicu_id": transaksiPemicuID,
+			"action":              "FindTagihanByPemicu",
+			"layer":               "repository",
+		}).Error("Gagal mencari tagihan biaya transaksi")
+		return model.TagihanBiaya{}, err
+	}
+	return tagihan, nil
+}
+
+func NewBiayaRepository(db *gorm.DB) BiayaRepository {
+	return &biayaRepository{db}
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type BukuBesarRepository interface {
+	CreateAkun(akun model.AkunBuku) (model.AkunBuku, error)
+	FindAkunByKode(kode string) (model.AkunBuku, error)
+	FindAkunByRekeningID(rekeningID int) (model.AkunBuku, error)
+	CreateJurnal(jurnal model.Jurnal) (model.Jurnal, error)
+	SaldoKewajiban(akunBukuID int) (model.Money, error)
+}
+
+type bukuBesarRepository struct {
+	db *gorm.DB
+}
+
+func (r *bukuBesarRepository) CreateAkun(akun model.AkunBuku) (model.AkunBuku, error) {
+	if err := r.db.Create(&akun).Error; err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"kode":   akun.Kode,
+			"action": "CreateAkun",
+			"layer":  "repository",
+		}).Error("Gagal membuat akun buku besar")
+		return model.AkunBuku{}, err
+	}
+	return akun, nil
+}
+
+func (r *bukuBesarRepository) FindAkunByKode(kode string) (model.AkunBuku, error) {
+	return r.findAkun("kode = ?", kode)
+}
+
+func (r *bukuBesarRepository) FindAkunByRekeningID(rekeningID int) (model.AkunBuku, error) {
+	return r.findAkun("rekening_id = ?", rekeningID)
+}
+
+func (r *bukuBesarRepository) findAkun(query string, arg interface{}) (model.AkunBuku, error) {
+	var akun model.AkunBuku
+	err := r.db.Where(query, arg).First(&akun).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.AkunBuku{}, nil
+	}
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"query":  query,
+			"arg":    arg,
+			"action": "findAkun",
+			"layer":  "repository",
+		}).Error("Gagal mencari akun buku besar")
+		return model.AkunBuku{}, err
+	}
+	return akun, nil
+}
+
+// CreateJurnal menyimpan jurnal beserta seluruh postingnya
+func (r *bukuBesarRepository) CreateJurnal(jurnal model.Jurnal) (model.Jurnal, error) {
+	if err := r.db.Create(&jurnal).Error; err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"no_referensi": jurnal.NoReferensi,
+			"keterangan":   jurnal.Keterangan,
+			"action":       "CreateJurnal",
+			"layer":        "repository",
+		}).Error("Gagal menyimpan jurnal")
+		return model.Jurnal{}, err
+	}
+	return jurnal, nil
+}
+
+// SaldoKewajiban menghitung saldo akun berjenis kewajiban dari postingnya, yaitu kredit dikurangi debit
+func (r *bukuBesarRepository) SaldoKewajiban(akunBukuID int) (model.Money, error) {
+	var saldo model.Money
+	err := r.db.Model(&model.Posting{}).
+		Select("COALESCE(SUM(kredit - debit), 0)").
+		Where("akun_buku_id = ?", akunBukuID).
+		Scan(&saldo).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"akun_buku_id": akunBukuID,
+			"action":       "SaldoKewajiban",
+			"layer":        "repository",
+		}).Error("Gagal menghitung saldo akun dari posting")
+		return 0, err
+	}
+	return saldo, nil
+}
+
+func NewBukuBesarRepository(db *gorm.DB) BukuBesarRepository {
+	return &bukuBesarRepository{db}
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BungaRepository interface {
+	FindSukuBunga(kodeProduk string, tanggal time.Time) ([]model.SukuBunga, error)
+	CreateBungaHarian(bunga model.BungaHarian) error
+	CountBungaHarian(tanggal time.Time) (int, error)
+	CreateAkru(akru model.AkruBunga) error
+	IsDiakru(tanggal time.Time) (bool, error)
+	FindTanggalBelumDiakru() ([]time.Time, error)
+	FindPeriodeBelumDikreditkan() ([]time.Time, error)
+	FindRekeningBelumDikreditkan(dari, sampai time.Time, afterID int, limit int) ([]model.Rekening, error)
+	RekapBelumDikreditkan(rekeningID int, dari, sampai time.Time) (model.RekapBunga, error)
+	TandaiDikreditkan(rekeningID int, dari, sampai time.Time, transaksiID *int) error
+	BatalkanKredit(transaksiID int) error
+}
+
+type bungaRepository struct {
+	db *gorm.DB
+}
+
+// FindSukuBunga mengambil tier suku bunga produk yang berlaku pada tanggal tersebut,
+// terurut dari saldo minimum terbesar
+func (r *bungaRepository) FindSukuBunga(kodeProduk string, tanggal time.Time) ([]model.SukuBunga, error) {
+	var tier []model.SukuBunga
+	err := r.db.
+		Where("kode_produk = ? AND berlaku_mulai = (SELECT MAX(berlaku_mulai) FROM suku_bunga WHERE kode_produk = ? AND berlaku_mulai <= ?)",
+			kodeProduk, kodeProduk, tanggal.Format(formatTanggal)).
+		Order("saldo_minimum DESC").
+		Find(&tier).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"kode_produk": kodeProduk,
+			"tanggal":     tanggal.Format(formatTanggal),
+			"action":      "FindSukuBunga",
+			"layer":       "repository",
+		}).Error("Gagal mengambil suku bunga")
+		return nil, err
+	}
+	return tier, nil
+}
+
+// CreateBungaHarian menyimpan akru bunga. Akru yang sudah ada tidak ditimpa.
+func (r *bungaRepository) CreateBungaHarian(bunga model.BungaHarian) error {
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&bunga).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"tanggal":     bunga.Tanggal.Format(formatTanggal),
+			"rekening_id": bunga.RekeningID,
+			"action":      "CreateBungaHarian",
+			"layer":       "repository",
+		}).Error("Gagal menyimpan bunga harian")
+	}
+	return err
+}
+
+func (r *bungaRepository) CountBungaHarian(tanggal time.Time) (int, error) {
+	var jumlah int64
+	err := r.db.Model(&model.BungaHarian{}).Where("tanggal = ?", tanggal.Format(formatTanggal)).Count(&jumlah).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"tanggal": tanggal.Format(formatTanggal),
+			"action":  "CountBungaHarian",
+			"layer":   "repository",
+		}).Error("Gagal menghitung bunga harian")
+		return 0, err
+	}
+	return int(jumlah), nil
+}
+
+func (r *bungaRepository) CreateAkru(akru model.AkruBunga) error {
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&akru).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"tanggal": akru.Tanggal.Format(formatTanggal),
+			"action":  "CreateAkru",
+			"layer":   "repository",
+		}).Error("Gagal mencatat akru bunga")
+	}
+	return err
+}
+
+func (r *bungaRepository) IsDiakru(tanggal time.Time) (bool, error) {
+	var jumlah int64
+	err := r.db.Model(&model.AkruBunga{}).Where("tanggal = ?", tanggal.Format(formatTanggal)).Count(&jumlah).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"tanggal": tanggal.Format(formatTanggal),
+			"action":  "IsDiakru",
+			"layer":   "repository",
+		}).Error("Gagal memeriksa akru bunga")
+		return false, err
+	}
+	return jumlah > 0, nil
+}
+
+// FindTanggalBelumDiakru mengambil tanggal buku yang sudah selesai ditutup tetapi belum diakru
+func (r *bungaRepository) FindTanggalBelumDiakru() ([]time.Time, error) {
+	var tanggal []time.Time
+	err := r.db.Model(&model.TutupHari{}).
+		Where("status = ? AND tanggal NOT IN (SELECT tanggal FROM akru_bunga)", model.StatusTutupHariSelesai).
+		Order("tanggal ASC").
+		Pluck("tanggal", &tanggal).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"action": "FindTanggalBelumDiakru",
+			"layer":  "repository",
+		}).Error("Gagal mengambil tanggal yang belum diakru")
+		return nil, err
+	}
+	return tanggal, nil
+}
+
+// FindPeriodeBelumDikreditkan mengambil awal bulan dari setiap periode yang masih punya akru
+// bunga yang belum dikreditkan
+func (r *bungaRepository) FindPeriodeBelumDikreditkan() ([]time.Time, error) {
+	var periode []time.Time
+	err := r.db.Model(&model.BungaHarian{}).
+		Distinct("DATE_TRUNC('month', tanggal)::date AS periode").
+		Where("NOT dikreditkan").
+		Order("periode ASC").
+		Pluck("periode", &periode).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"action": "FindPeriodeBelumDikreditkan",
+			"layer":  "repository",
+		}).Error("Gagal mengambil periode bunga yang belum dikreditkan")
+		return nil, err
+	}
+	return periode, nil
+}
+
+// FindRekeningBelumDikreditkan membaca per halaman rekening yang masih punya akru bunga
+// belum dikreditkan pada rentang [dari, sampai)
+func (r *bungaRepository) FindRekeningBelumDikreditkan(dari, sampai time.Time, afterID int, limit int) ([]model.Rekening, error) {
+	var rekening []model.Rekening
+	err := r.db.
+		Where("id > ? AND id IN (SELECT rekening_id FROM bunga_harian WHERE NOT dikreditkan AND tanggal >= ? AND tanggal < ?)",
+			afterID, dari.Format(formatTanggal), sampai.Format(formatTanggal)).
+		Order("id ASC").
+		Limit(limit).
+		Find(&rekening).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"dari":     dari.Format(formatTanggal),
+			"sampai":   sampai.Format(formatTanggal),
+			"after_id": afterID,
+			"action":   "FindRekeningBelumDikreditkan",
+			"layer":    "repository",
+		}).Error("Gagal mengambil rekening dengan bunga belum dikreditkan")
+		return nil, err
+	}
+	return rekening, nil
+}
+
+func (r *bungaRepository) RekapBelumDikreditkan(rekeningID int, dari, sampai time.Time) (model.RekapBunga, error) {
+	var rekap model.RekapBunga
+	err := r.db.Model(&model.BungaHarian{}).
+		Select("COALESCE(SUM(bunga_mikrosen), 0) AS total_mikrosen, COUNT(*) AS jumlah_hari").
+		Where("rekening_id = ? AND NOT dikreditkan AND tanggal >= ? AND tanggal < ?",
+			rekeningID, dari.Format(formatTanggal), sampai.Format(formatTanggal)).
+		Scan(&rekap).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"rekening_id": rekeningID,
+			"dari":        dari.Format(formatTanggal),
+			"action":      "RekapBelumDikreditkan",
+			"layer":       "repository",
+		}).Error("Gagal menjumlahkan akru bunga")
+		return model.RekapBunga{}, err
+	}
+	return rekap, nil
+}
+
+// TandaiDikreditkan menandai akru bunga rekening pada rentang [dari, sampai) sudah dikreditkan.
+// transaksiID nil jika bunga yang terkumpul kurang dari satu sen.
+func (r *bungaRepository) TandaiDikreditkan(rekeningID int, dari, sampai time.Time, transaksiID *int) error {
+	err := r.db.Model(&model.BungaHarian{}).
+		Where("rekening_id = ? AND NOT dikreditkan AND tanggal >= ? AND tanggal < ?",
+			rekeningID, dari.Format(formatTanggal), sampai.Format(formatTanggal)).
+		Updates(map[string]interface{}{
+			"dikreditkan":  true,
+			"transaksi_id": transaksiID,
+		}).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"rekening_id": rekeningID,
+			"dari":        dari.Format(formatTanggal),
+			"action":      "TandaiDikreditkan",
+			"layer":       "repository",
+		}).Error("Gagal menandai bunga sudah dikreditkan")
+	}
+	return err
+}
+
+// BatalkanKredit mengembalikan akru bunga yang dikreditkan lewat transaksiID menjadi belum
+// dikreditkan, sehingga periode tersebut bisa dikreditkan ulang setelah bunganya dikoreksi
+func (r *bungaRepository) BatalkanKredit(transaksiID int) error {
+	err := r.db.Model(&model.BungaHarian{}).
+		Where("transaksi_id = ?", transaksiID).
+		Updates(map[string]interface{}{
+			"dikreditkan":  false,
+			"transaksi_id": nil,
+		}).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"transaksi_id": transaksiID,
+			"action":       "BatalkanKredit",
+			"layer":        "repository",
+		}).Error("Gagal membatalkan kredit bunga")
+	}
+	return err
+}
+
+func NewBungaRepository(db *gorm.DB) BungaRepository {
+	return &bungaRepository{db}
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Nama constraint unique bawaan Postgres dari bank-api.sql
+const (
+	ConstraintNasabahNIK    = "nasabah_nik_key"
+	ConstraintNasabahNoHP   = "nasabah_no_hp_key"
+	ConstraintRekeningNoREK = "rekening_no_rekening_key"
+)
+
+// ConstraintIdempotencyKunci menolak Idempotency-Key yang sama untuk endpoint yang sama
+const ConstraintIdempotencyKunci = "idempotency_key_kunci_endpoint_key"
+
+const pgUniqueViolation = "23505"
+
+// DuplicateError menandai insert/update yang ditolak oleh constraint unique
+type DuplicateError struct {
+	Constraint string
+	Err        error
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("data duplikat (%s): %v", e.Constraint, e.Err)
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return e.Err
+}
+
+// IsDuplicate memeriksa apakah err disebabkan constraint unique tertentu
+func IsDuplicate(err error, constraint string) bool {
+	var dupErr *DuplicateError
+	return errors.As(err, &dupErr) && dupErr.Constraint == constraint
+}
+
+// translateError mengubah unique violation Postgres menjadi DuplicateError, error lain dikembalikan apa adanya
+func translateError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return &DuplicateError{Constraint: pgErr.ConstraintName, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type IdempotencyRepository interface {
+	Create(newKey model.IdempotencyKey) (model.IdempotencyKey, error)
+	FindByKunci(kunci, endpoint string) (model.IdempotencyKey, error)
+	Complete(id int, statusCode int, responseBody string) error
+	AmbilAlih(id int, batas time.Time) (bool, error)
+	Perpanjang(id int) error
+	Delete(id int) error
+}
+
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func (r *idempotencyRepository) Create(newKey model.IdempotencyKey) (model.IdempotencyKey, error) {
+	result := r.db.Create(&newKey)
+	if result.Error != nil {
+		utils.Log.WithError(result.Error).WithFields(logrus.Fields{
+			"kunci":    newKey.Kunci,
+			"endpoint": newKey.Endpoint,
+			"action":   "create idempotency key",
+			"layer":    "repository",
+		}).Error("Gagal menyimpan idempotency key")
+		return model.IdempotencyKey{}, translateError(result.Error)
+	}
+	return newKey, nil
+}
+
+func (r *idempotencyRepository) FindByKunci(kunci, endpoint string) (model.IdempotencyKey, error) {
+	var key model.IdempotencyKey
+	err := r.db.Where("kunci = ? AND endpoint = ?", kunci, endpoint).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.IdempotencyKey{}, nil
+	}
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"kunci":    kunci,
+			"endpoint": endpoint,
+			"error":    err,
+			"action":   "FindByKunci",
+			"layer":    "repository",
+		}).Error("Gagal mencari idempotency key")
+		return model.IdempotencyKey{}, err
+	}
+	return key, nil
+}
+
+func (r *idempotencyRepository) Complete(id int, statusCode int, responseBody string) error {
+	return r.db.Model(&model.IdempotencyKey{ID: id}).Updates(map[string]interface{}{
+		"status_code":   statusCode,
+		"response_body": responseBody,
+	}).Error
+}
+
+// AmbilAlih memulai ulang key yang masih diproses tetapi tidak diperpanjang lagi sejak sebelum
+// batas. Hanya satu request yang berhasil mengambil alih karena update bersyarat pada
+// updated_at lama.
+func (r *idempotencyRepository) AmbilAlih(id int, batas time.Time) (bool, error) {
+	result := r.db.Model(&model.IdempotencyKey{}).
+		Where("id = ? AND status_code = 0 AND updated_at < ?", id, batas).
+		Update("updated_at", time.Now())
+	if result.Error != nil {
+		utils.Log.WithError(result.Error).WithFields(logrus.Fields{
+			"id":     id,
+			"action": "AmbilAlih",
+			"layer":  "repository",
+		}).Error("Gagal mengambil alih idempotency key")
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Perpanjang menandai bahwa request pemegang key masih berjalan
+func (r *idempotencyRepository) Perpanjang(id int) error {
+	return r.db.Model(&model.IdempotencyKey{}).
+		Where("id = ? AND status_code = 0", id).
+		Update("updated_at", time.Now()).Error
+}
+
+func (r *idempotencyRepository) Delete(id int) error {
+	return r.db.Delete(&model.IdempotencyKey{ID: id}).Error
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{db}
+}
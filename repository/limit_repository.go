@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type LimitRepository interface {
+	FindBerlaku(kodeProduk, kycStatus, arah string) ([]model.LimitTransaksi, error)
+}
+
+type limitRepository struct {
+	db *gorm.DB
+}
+
+// FindBerlaku mengambil semua aturan batas yang cocok dengan produk, tier KYC dan arah transaksi
+func (r *limitRepository) FindBerlaku(kodeProduk, kycStatus, arah string) ([]model.LimitTransaksi, error) {
+	var limits []model.LimitTransaksi
+	err := r.db.
+		Where("arah = ?", arah).
+		Where("kode_produk IS NULL OR kode_produk = ?", kodeProduk).
+		Where("kyc_status IS NULL OR kyc_status = ?", kycStatus).
+		Find(&limits).Error
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"kode_produk": kodeProduk,
+			"kyc_status":  kycStatus,
+			"arah":        arah,
+			"error":       err,
+			"action":      "FindBerlaku",
+			"layer":       "repository",
+		}).Error("Gagal mengambil aturan batas transaksi")
+		return nil, err
+	}
+	return limits, nil
+}
+
+func NewLimitRepository(db *gorm.DB) LimitRepository {
+	return &limitRepository{db}
+}
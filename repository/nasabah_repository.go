@@ -7,12 +7,19 @@ import (
 	"github.com/sferawann/go-bank-api/utils"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type NasabahRepository interface {
 	Create(newNasabah model.Nasabah) (model.Nasabah, error)
 	FindByNIK(nik string) (model.Nasabah, error)
 	FindByNoHP(nohp string) (model.Nasabah, error)
+	FindByID(id int) (model.Nasabah, error)
+	FindByIDForUpdate(id int) (model.Nasabah, error)
+	Update(nasabah model.Nasabah) (model.Nasabah, error)
+	UpdatePin(nasabah model.Nasabah) error
+	UpdateKYC(nasabah model.Nasabah) error
+	FindByKYCStatus(status string) ([]model.Nasabah, error)
 }
 
 type nasabahRepository struct {
@@ -37,7 +44,7 @@ func (r *nasabahRepository) Create(newNasabah model.Nasabah) (model.Nasabah, err
 			"action": "create nasabah",
 			"layer":  "repository",
 		}).Error("Gagal membuat nasabah baru")
-		return model.Nasabah{}, result.Error
+		return model.Nasabah{}, translateError(result.Error)
 
 	}
 	utils.Log.WithFields(logrus.Fields{
@@ -93,6 +100,133 @@ func (r *nasabahRepository) FindByNoHP(nohp string) (model.Nasabah, error) {
 	return nasabah, err
 }
 
+func (r *nasabahRepository) FindByID(id int) (model.Nasabah, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"id":     id,
+		"action": "FindByID",
+		"layer":  "repository",
+	}).Info("Mencoba mencari nasabah berdasarkan ID")
+	var nasabah model.Nasabah
+	err := r.db.Where("id = ?", id).First(&nasabah).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Nasabah{}, nil
+	}
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"id":     id,
+			"error":  err,
+			"action": "FindByID",
+			"layer":  "repository",
+		}).Error("Gagal mencari nasabah berdasarkan ID")
+		return model.Nasabah{}, err
+	}
+	return nasabah, nil
+}
+
+// FindByIDForUpdate mengunci baris nasabah sampai transaksi database selesai
+func (r *nasabahRepository) FindByIDForUpdate(id int) (model.Nasabah, error) {
+	var nasabah model.Nasabah
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&nasabah).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Nasabah{}, nil
+	}
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"id":     id,
+			"error":  err,
+			"action": "FindByIDForUpdate",
+			"layer":  "repository",
+		}).Error("Gagal mengunci nasabah")
+		return model.Nasabah{}, err
+	}
+	return nasabah, nil
+}
+
+// Update menyimpan data profil nasabah yang boleh diubah, yaitu nama dan no hp
+func (r *nasabahRepository) Update(nasabah model.Nasabah) (model.Nasabah, error) {
+	err := r.db.Model(&nasabah).Updates(map[string]interface{}{
+		"nama":  nasabah.Nama,
+		"no_hp": nasabah.NoHP,
+	}).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"id":     nasabah.ID,
+			"action": "Update",
+			"layer":  "repository",
+		}).Error("Gagal mengubah profil nasabah")
+		return model.Nasabah{}, translateError(err)
+	}
+	utils.Log.WithFields(logrus.Fields{
+		"id":     nasabah.ID,
+		"action": "Update",
+		"layer":  "repository",
+	}).Info("Profil nasabah berhasil diubah")
+	return nasabah, nil
+}
+
+// UpdatePin menyimpan hash PIN beserta jumlah gagal dan status kunci PIN
+func (r *nasabahRepository) UpdatePin(nasabah model.Nasabah) error {
+	err := r.db.Model(&model.Nasabah{ID: nasabah.ID}).Updates(map[string]interface{}{
+		"pin_hash":        nasabah.PinHash,
+		"pin_gagal":       nasabah.PinGagal,
+		"pin_terkunci_at": nasabah.PinTerkunciAt,
+	}).Error
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"id":     nasabah.ID,
+			"error":  err,
+			"action": "UpdatePin",
+			"layer":  "repository",
+		}).Error("Gagal menyimpan PIN nasabah")
+	}
+	return err
+}
+
+// UpdateKYC menyimpan seluruh data KYC beserta status verifikasinya
+func (r *nasabahRepository) UpdateKYC(nasabah model.Nasabah) error {
+	kyc := nasabah.KYC
+	err := r.db.Model(&model.Nasabah{ID: nasabah.ID}).Updates(map[string]interface{}{
+		"tanggal_lahir":         kyc.TanggalLahir,
+		"tempat_lahir":          kyc.TempatLahir,
+		"alamat":                kyc.Alamat,
+		"nama_ibu_kandung":      kyc.NamaIbuKandung,
+		"pekerjaan":             kyc.Pekerjaan,
+		"rentang_penghasilan":   kyc.RentangPenghasilan,
+		"email":                 kyc.Email,
+		"no_dokumen":            kyc.NoDokumen,
+		"kyc_status":            kyc.Status,
+		"kyc_catatan":           kyc.Catatan,
+		"kyc_diverifikasi_oleh": kyc.DiverifikasiOleh,
+		"kyc_diverifikasi_at":   kyc.DiverifikasiAt,
+	}).Error
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"id":         nasabah.ID,
+			"kyc_status": kyc.Status,
+			"error":      err,
+			"action":     "UpdateKYC",
+			"layer":      "repository",
+		}).Error("Gagal menyimpan data KYC nasabah")
+	}
+	return err
+}
+
+// FindByKYCStatus dipakai back-office untuk melihat antrean verifikasi KYC, yang terlama di depan
+func (r *nasabahRepository) FindByKYCStatus(status string) ([]model.Nasabah, error) {
+	var nasabah []model.Nasabah
+	err := r.db.Where("kyc_status = ?", status).Order("updated_at ASC, id ASC").Find(&nasabah).Error
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"kyc_status": status,
+			"error":      err,
+			"action":     "FindByKYCStatus",
+			"layer":      "repository",
+		}).Error("Gagal mengambil nasabah berdasarkan status KYC")
+		return nil, err
+	}
+	return nasabah, nil
+}
+
 func NewNasabahRepository(db *gorm.DB) NasabahRepository {
 	return &nasabahRepository{db}
 }
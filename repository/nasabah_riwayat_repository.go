@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type NasabahRiwayatRepository interface {
+	Create(riwayat model.NasabahRiwayat) (model.NasabahRiwayat, error)
+	FindByNasabahID(nasabahID int) ([]model.NasabahRiwayat, error)
+}
+
+type nasabahRiwayatRepository struct {
+	db *gorm.DB
+}
+
+func (r *nasabahRiwayatRepository) Create(riwayat model.NasabahRiwayat) (model.NasabahRiwayat, error) {
+	if err := r.db.Create(&riwayat).Error; err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"nasabah_id": riwayat.NasabahID,
+			"field":      riwayat.Field,
+			"action":     "create riwayat nasabah",
+			"layer":      "repository",
+		}).Error("Gagal mencatat riwayat perubahan nasabah")
+		return model.NasabahRiwayat{}, err
+	}
+	return riwayat, nil
+}
+
+func (r *nasabahRiwayatRepository) FindByNasabahID(nasabahID int) ([]model.NasabahRiwayat, error) {
+	var riwayat []model.NasabahRiwayat
+	err := r.db.Where("nasabah_id = ?", nasabahID).Order("created_at DESC, id DESC").Find(&riwayat).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"nasabah_id": nasabahID,
+			"action":     "FindByNasabahID",
+			"layer":      "repository",
+		}).Error("Gagal mengambil riwayat perubahan nasabah")
+		return nil, err
+	}
+	return riwayat, nil
+}
+
+func NewNasabahRiwayatRepository(db *gorm.DB) NasabahRiwayatRepository {
+	return &nasabahRiwayatRepository{db}
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type ProdukRepository interface {
+	FindByKode(kode string) (model.Produk, error)
+}
+
+type produkRepository struct {
+	db *gorm.DB
+}
+
+func (r *produkRepository) FindByKode(kode string) (model.Produk, error) {
+	var produk model.Produk
+	err := r.db.Where("kode = ?", kode).First(&produk).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Produk{}, nil
+	}
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"kode":   kode,
+			"error":  err,
+			"action": "FindByKode",
+			"layer":  "repository",
+		}).Error("Gagal mencari produk")
+		return model.Produk{}, err
+	}
+	return produk, nil
+}
+
+func NewProdukRepository(db *gorm.DB) ProdukRepository {
+	return &produkRepository{db}
+}
@@ -2,18 +2,25 @@ package repository
 
 import (
 	"errors"
+	"time"
 
 	"github.com/sferawann/go-bank-api/model"
 	"github.com/sferawann/go-bank-api/utils"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RekeningRepository interface {
 	Create(newRekening model.Rekening) (model.Rekening, error)
 	FindByNasabahID(nasabahID int) (model.Rekening, error)
+	FindAllByNasabahID(nasabahID int) ([]model.Rekening, error)
 	FindByNoREK(noREK string) (model.Rekening, error)
+	FindByNoREKForUpdate(noREK string) (model.Rekening, error)
 	UpdateSaldo(UpdateRekening model.Rekening) (model.Rekening, error)
+	UpdateStatus(rekening model.Rekening) error
+	FindAfterID(afterID int, limit int) ([]model.Rekening, error)
+	FindDibukaSebelum(sebelum time.Time, afterID int, limit int) ([]model.Rekening, error)
 }
 
 type rekeningRepository struct {
@@ -24,6 +31,7 @@ func (r *rekeningRepository) Create(newRekening model.Rekening) (model.Rekening,
 	utils.Log.WithFields(logrus.Fields{
 		"nasabah_id":  newRekening.NasabahID,
 		"no_rekening": newRekening.NoRekening,
+		"kode_produk": newRekening.KodeProduk,
 		"saldo":       newRekening.Saldo,
 		"action":      "create rekening",
 		"layer":       "repository",
@@ -38,7 +46,7 @@ func (r *rekeningRepository) Create(newRekening model.Rekening) (model.Rekening,
 			"action":      "create rekening",
 			"layer":       "repository",
 		}).Error("Gagal membuat rekening")
-		return model.Rekening{}, result.Error
+		return model.Rekening{}, translateError(result.Error)
 	}
 	utils.Log.WithFields(logrus.Fields{
 		"id":          newRekening.ID,
@@ -85,14 +93,49 @@ func (r *rekeningRepository) FindByNasabahID(nasabahID int) (model.Rekening, err
 	return rekening, nil
 }
 
+func (r *rekeningRepository) FindAllByNasabahID(nasabahID int) ([]model.Rekening, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"nasabah_id": nasabahID,
+		"action":     "FindAllByNasabahID",
+		"layer":      "repository",
+	}).Info("Mencari semua rekening milik nasabah")
+	var rekening []model.Rekening
+	err := r.db.Where("nasabah_id = ?", nasabahID).Order("id ASC").Find(&rekening).Error
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"nasabah_id": nasabahID,
+			"error":      err,
+			"action":     "FindAllByNasabahID",
+			"layer":      "repository",
+		}).Error("Gagal mencari rekening milik nasabah")
+		return nil, err
+	}
+	return rekening, nil
+}
+
 func (r *rekeningRepository) UpdateSaldo(UpdateRekening model.Rekening) (model.Rekening, error) {
-	result := r.db.Save(&UpdateRekening)
+	// hanya kolom saldo yang ditulis agar tidak menimpa perubahan kolom lain
+	result := r.db.Model(&model.Rekening{ID: UpdateRekening.ID}).Update("saldo", UpdateRekening.Saldo)
 	if result.Error != nil {
 		return model.Rekening{}, result.Error
 	}
 	return UpdateRekening, nil
 }
 
+func (r *rekeningRepository) UpdateStatus(rekening model.Rekening) error {
+	err := r.db.Model(&model.Rekening{ID: rekening.ID}).Update("status", rekening.Status).Error
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"rekening_id": rekening.ID,
+			"status":      rekening.Status,
+			"error":       err,
+			"action":      "UpdateStatus",
+			"layer":       "repository",
+		}).Error("Gagal mengubah status rekening")
+	}
+	return err
+}
+
 func (r *rekeningRepository) FindByNoREK(noREK string) (model.Rekening, error) {
 	utils.Log.WithFields(logrus.Fields{
 		"no_rekening": noREK,
@@ -126,6 +169,69 @@ func (r *rekeningRepository) FindByNoREK(noREK string) (model.Rekening, error) {
 	return rekening, err
 }
 
+// FindByNoREKForUpdate mengunci baris rekening (SELECT ... FOR UPDATE) sampai transaksi
+// database selesai, sehingga hanya boleh dipanggil di dalam UnitOfWork.
+func (r *rekeningRepository) FindByNoREKForUpdate(noREK string) (model.Rekening, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"no_rekening": noREK,
+		"action":      "FindByNoREKForUpdate",
+		"layer":       "repository",
+	}).Info("Mengunci rekening berdasarkan noREK")
+	var rekening model.Rekening
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("no_rekening = ?", noREK).First(&rekening).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.Log.WithFields(logrus.Fields{
+			"no_rekening": noREK,
+			"action":      "FindByNoREKForUpdate",
+			"layer":       "repository",
+		}).Warn("No rekening tidak ditemukan")
+		return model.Rekening{}, nil
+	}
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"no_rekening": noREK,
+			"error":       err,
+			"action":      "FindByNoREKForUpdate",
+			"layer":       "repository",
+		}).Error("Gagal mengunci rekening")
+		return model.Rekening{}, err
+	}
+	return rekening, nil
+}
+
+// FindAfterID membaca rekening berurutan per halaman untuk job batch
+func (r *rekeningRepository) FindAfterID(afterID int, limit int) ([]model.Rekening, error) {
+	var rekening []model.Rekening
+	err := r.db.Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&rekening).Error
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"after_id": afterID,
+			"error":    err,
+			"action":   "FindAfterID",
+			"layer":    "repository",
+		}).Error("Gagal membaca daftar rekening")
+		return nil, err
+	}
+	return rekening, nil
+}
+
+// FindDibukaSebelum seperti FindAfterID tetapi hanya rekening yang sudah dibuka sebelum waktu tertentu
+func (r *rekeningRepository) FindDibukaSebelum(sebelum time.Time, afterID int, limit int) ([]model.Rekening, error) {
+	var rekening []model.Rekening
+	err := r.db.Where("id > ? AND created_at < ?", afterID, sebelum).Order("id ASC").Limit(limit).Find(&rekening).Error
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"sebelum":  sebelum,
+			"after_id": afterID,
+			"error":    err,
+			"action":   "FindDibukaSebelum",
+			"layer":    "repository",
+		}).Error("Gagal membaca daftar rekening")
+		return nil, err
+	}
+	return rekening, nil
+}
+
 func NewRekeningRepository(db *gorm.DB) RekeningRepository {
 	return &rekeningRepository{db}
 }
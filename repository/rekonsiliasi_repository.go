@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type RekonsiliasiRepository interface {
+	Create(rekonsiliasi model.Rekonsiliasi) (model.Rekonsiliasi, error)
+	Selesai(rekonsiliasi model.Rekonsiliasi) error
+	CreateSelisih(selisih model.RekonsiliasiSelisih) (model.RekonsiliasiSelisih, error)
+	FindTerbaru(limit int) ([]model.Rekonsiliasi, error)
+	FindByID(id int) (model.Rekonsiliasi, error)
+	Eksklusif(fn func() error) (bool, error)
+}
+
+// kunciRekonsiliasi adalah nama advisory lock yang dipegang selama satu rekonsiliasi berjalan
+const kunciRekonsiliasi = "rekonsiliasi"
+
+type rekonsiliasiRepository struct {
+	db *gorm.DB
+}
+
+func (r *rekonsiliasiRepository) Create(rekonsiliasi model.Rekonsiliasi) (model.Rekonsiliasi, error) {
+	if err := r.db.Create(&rekonsiliasi).Error; err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"pemicu": rekonsiliasi.Pemicu,
+			"action": "create rekonsiliasi",
+			"layer":  "repository",
+		}).Error("Gagal mencatat rekonsiliasi")
+		return model.Rekonsiliasi{}, err
+	}
+	return rekonsiliasi, nil
+}
+
+// Selesai menyimpan ringkasan hasil, status akhir dan waktu selesai rekonsiliasi
+func (r *rekonsiliasiRepository) Selesai(rekonsiliasi model.Rekonsiliasi) error {
+	err := r.db.Model(&model.Rekonsiliasi{ID: rekonsiliasi.ID}).Updates(map[string]interface{}{
+		"status":          rekonsiliasi.Status,
+		"pesan_gagal":     rekonsiliasi.PesanGagal,
+		"jumlah_rekening": rekonsiliasi.JumlahRekening,
+		"jumlah_selisih":  rekonsiliasi.JumlahSelisih,
+		"selesai_at":      rekonsiliasi.SelesaiAt,
+	}).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"id":     rekonsiliasi.ID,
+			"action": "Selesai",
+			"layer":  "repository",
+		}).Error("Gagal menyimpan hasil rekonsiliasi")
+	}
+	return err
+}
+
+func (r *rekonsiliasiRepository) CreateSelisih(selisih model.RekonsiliasiSelisih) (model.RekonsiliasiSelisih, error) {
+	if err := r.db.Create(&selisih).Error; err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"rekonsiliasi_id": selisih.RekonsiliasiID,
+			"no_rekening":     selisih.NoRekening,
+			"action":          "CreateSelisih",
+			"layer":           "repository",
+		}).Error("Gagal mencatat selisih rekonsiliasi")
+		return model.RekonsiliasiSelisih{}, err
+	}
+	return selisih, nil
+}
+
+func (r *rekonsiliasiRepository) FindTerbaru(limit int) ([]model.Rekonsiliasi, error) {
+	var rekonsiliasi []model.Rekonsiliasi
+	err := r.db.Order("id DESC").Limit(limit).Find(&rekonsiliasi).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"action": "FindTerbaru",
+			"layer":  "repository",
+		}).Error("Gagal mengambil daftar rekonsiliasi")
+		return nil, err
+	}
+	return rekonsiliasi, nil
+}
+
+// FindByID mengambil satu rekonsiliasi beserta seluruh selisihnya
+func (r *rekonsiliasiRepository) FindByID(id int) (model.Rekonsiliasi, error) {
+	var rekonsiliasi model.Rekonsiliasi
+	err := r.db.Preload("Selisih", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Where("id = ?", id).First(&rekonsiliasi).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Rekonsiliasi{}, nil
+	}
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"id":     id,
+			"action": "FindByID",
+			"layer":  "repository",
+		}).Error("Gagal mengambil rekonsiliasi")
+		return model.Rekonsiliasi{}, err
+	}
+	return rekonsiliasi, nil
+}
+
+// Eksklusif menjalankan fn sambil memegang advisory lock rekonsiliasi di satu koneksi
+// tersendiri, sehingga jadwal, CLI dan endpoint admin di proses mana pun tidak bisa
+// merekonsiliasi bersamaan. Mengembalikan false tanpa menjalankan fn jika lock dipegang
+// pihak lain. Lock ikut lepas bila proses pemegangnya mati karena koneksinya terputus.
+func (r *rekonsiliasiRepository) Eksklusif(fn func() error) (bool, error) {
+	var dijalankan bool
+	err := r.db.Connection(func(conn *gorm.DB) error {
+		var dapat bool
+		if err := conn.Raw("SELECT pg_try_advisory_lock(hashtextextended(?, 0))", kunciRekonsiliasi).Scan(&dapat).Error; err != nil {
+			utils.Log.WithError(err).WithFields(logrus.Fields{
+				"action": "Eksklusif",
+				"layer":  "repository",
+			}).Error("Gagal mengambil lock rekonsiliasi")
+			return err
+		}
+		if !dapat {
+			return nil
+		}
+		defer func() {
+			if err := conn.Exec("SELECT pg_advisory_unlock(hashtextextended(?, 0))", kunciRekonsiliasi).Error; err != nil {
+				utils.Log.WithError(err).WithFields(logrus.Fields{
+					"action": "Eksklusif",
+					"layer":  "repository",
+				}).Error("Gagal melepas lock rekonsiliasi")
+			}
+		}()
+		dijalankan = true
+		return fn()
+	})
+	return dijalankan, err
+}
+
+func NewRekonsiliasiRepository(db *gorm.DB) RekonsiliasiRepository {
+	return &rekonsiliasiRepository{db}
+}
@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/sferawann/go-bank-api/model"
 	"github.com/sferawann/go-bank-api/utils"
@@ -12,7 +14,19 @@ import (
 type TransaksiRepository interface {
 	Tarik(newTarik model.Transaksi) (model.Transaksi, error)
 	Tabung(newTabung model.Transaksi) (model.Transaksi, error)
+	Create(newTransaksi model.Transaksi) (model.Transaksi, error)
 	FindByRekeningID(rekeningID int) (model.Transaksi, error)
+	FindMutasi(filter model.MutasiFilter) ([]model.Transaksi, int64, error)
+	RekapHarian(rekeningID int, jenis []string, dari, sampai time.Time) (model.RekapHarian, error)
+	FindByID(id int) (model.Transaksi, error)
+	FindByNoReferensi(noReferensi string) ([]model.Transaksi, error)
+	FindByTransaksiAsalID(transaksiAsalID int) (model.Transaksi, error)
+	FindDipicuBelumDikoreksi(transaksiPemicuID int) ([]model.Transaksi, error)
+	FindByIdempotencyKeyID(idempotencyKeyID int) (model.Transaksi, error)
+	SaldoDariTransaksi(rekeningID int) (model.Money, error)
+	WaktuTransaksiPertama() (time.Time, error)
+	SaldoHarian(rekeningID int, dari, sampai time.Time) (model.SaldoHarian, error)
+	TotalPerJenis(dari, sampai time.Time) ([]model.TutupHariTotal, error)
 }
 
 type transaksiRepository struct {
@@ -75,6 +89,37 @@ func (r *transaksiRepository) Tabung(newTabung model.Transaksi) (model.Transaksi
 	return newTabung, nil
 }
 
+func (r *transaksiRepository) Create(newTransaksi model.Transaksi) (model.Transaksi, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"rekening_id":     newTransaksi.RekeningID,
+		"nominal":         newTransaksi.Nominal,
+		"jenis_transaksi": newTransaksi.JenisTransaksi,
+		"no_referensi":    newTransaksi.NoReferensi,
+		"action":          "create transaksi",
+		"layer":           "repository",
+	}).Info("Mencoba membuat transaksi baru")
+	result := r.db.Create(&newTransaksi)
+	if result.Error != nil {
+		utils.Log.WithError(result.Error).WithFields(logrus.Fields{
+			"rekening_id":     newTransaksi.RekeningID,
+			"nominal":         newTransaksi.Nominal,
+			"jenis_transaksi": newTransaksi.JenisTransaksi,
+			"action":          "create transaksi",
+			"layer":           "repository",
+		}).Error("Gagal membuat transaksi baru")
+		return model.Transaksi{}, result.Error
+	}
+	utils.Log.WithFields(logrus.Fields{
+		"id":              newTransaksi.ID,
+		"rekening_id":     newTransaksi.RekeningID,
+		"nominal":         newTransaksi.Nominal,
+		"jenis_transaksi": newTransaksi.JenisTransaksi,
+		"action":          "create transaksi",
+		"layer":           "repository",
+	}).Info("berhasil membuat transaksi baru")
+	return newTransaksi, nil
+}
+
 func (r *transaksiRepository) FindByRekeningID(rekeningID int) (model.Transaksi, error) {
 	utils.Log.WithFields(logrus.Fields{
 		"rekening_id": rekeningID,
@@ -111,6 +156,265 @@ func (r *transaksiRepository) FindByRekeningID(rekeningID int) (model.Transaksi,
 	return transaksi, nil
 }
 
+func (r *transaksiRepository) FindMutasi(filter model.MutasiFilter) ([]model.Transaksi, int64, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"rekening_id":     filter.RekeningID,
+		"dari":            filter.Dari,
+		"sampai":          filter.Sampai,
+		"jenis_transaksi": filter.JenisTransaksi,
+		"sort":            filter.Sort,
+		"page":            filter.Page,
+		"limit":           filter.Limit,
+		"action":          "FindMutasi",
+		"layer":           "repository",
+	}).Info("Mencari mutasi rekening")
+
+	query := r.db.Model(&model.Transaksi{}).Where("rekening_id = ?", filter.RekeningID)
+	if filter.Dari != nil {
+		query = query.Where("created_at >= ?", *filter.Dari)
+	}
+	if filter.Sampai != nil {
+		query = query.Where("created_at < ?", *filter.Sampai)
+	}
+	if filter.JenisTransaksi != "" {
+		query = query.Where("jenis_transaksi = ?", filter.JenisTransaksi)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"rekening_id": filter.RekeningID,
+			"error":       err,
+			"action":      "FindMutasi",
+			"layer":       "repository",
+		}).Error("Gagal menghitung jumlah mutasi")
+		return nil, 0, err
+	}
+
+	order := "created_at DESC, id DESC"
+	if filter.Sort == model.SortAsc {
+		order = "created_at ASC, id ASC"
+	}
+
+	var transaksi []model.Transaksi
+	err := query.Order(order).Offset((filter.Page - 1) * filter.Limit).Limit(filter.Limit).Find(&transaksi).Error
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"rekening_id": filter.RekeningID,
+			"error":       err,
+			"action":      "FindMutasi",
+			"layer":       "repository",
+		}).Error("Gagal mencari mutasi rekening")
+		return nil, 0, err
+	}
+	utils.Log.WithFields(logrus.Fields{
+		"rekening_id": filter.RekeningID,
+		"jumlah":      len(transaksi),
+		"total":       total,
+		"action":      "FindMutasi",
+		"layer":       "repository",
+	}).Info("Mutasi rekening ditemukan")
+	return transaksi, total, nil
+}
+
+func (r *transaksiRepository) FindByID(id int) (model.Transaksi, error) {
+	var transaksi model.Transaksi
+	err := r.db.Preload("Rekening").Where("id = ?", id).First(&transaksi).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Transaksi{}, nil
+	}
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"id":     id,
+			"error":  err,
+			"action": "FindByID",
+			"layer":  "repository",
+		}).Error("Gagal mencari transaksi berdasarkan ID")
+		return model.Transaksi{}, err
+	}
+	return transaksi, nil
+}
+
+// FindByNoReferensi mengambil semua baris yang berbagi no_referensi, misalnya dua sisi transfer
+func (r *transaksiRepository) FindByNoReferensi(noReferensi string) ([]model.Transaksi, error) {
+	var transaksi []model.Transaksi
+	err := r.db.Preload("Rekening").Where("no_referensi = ?", noReferensi).Order("id ASC").Find(&transaksi).Error
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"no_referensi": noReferensi,
+			"error":        err,
+			"action":       "FindByNoReferensi",
+			"layer":        "repository",
+		}).Error("Gagal mencari transaksi berdasarkan no referensi")
+		return nil, err
+	}
+	return transaksi, nil
+}
+
+// FindByTransaksiAsalID mencari transaksi koreksi untuk transaksi asal, kosong jika belum dikoreksi
+func (r *transaksiRepository) FindByTransaksiAsalID(transaksiAsalID int) (model.Transaksi, error) {
+	var transaksi model.Transaksi
+	err := r.db.Where("transaksi_asal_id = ?", transaksiAsalID).First(&transaksi).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Transaksi{}, nil
+	}
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"transaksi_asal_id": transaksiAsalID,
+			"error":             err,
+			"action":            "FindByTransaksiAsalID",
+			"layer":             "repository",
+		}).Error("Gagal mencari transaksi koreksi")
+		return model.Transaksi{}, err
+	}
+	return transaksi, nil
+}
+
+// FindByIdempotencyKeyID mengambil transaksi yang sudah ter-commit untuk sebuah Idempotency-Key
+func (r *transaksiRepository) FindByIdempotencyKeyID(idempotencyKeyID int) (model.Transaksi, error) {
+	var transaksi model.Transaksi
+	err := r.db.Where("idempotency_key_id = ?", idempotencyKeyID).First(&transaksi).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.Transaksi{}, nil
+	}
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"idempotency_key_id": idempotencyKeyID,
+			"error":              err,
+			"action":             "FindByIdempotencyKeyID",
+			"layer":              "repository",
+		}).Error("Gagal mencari transaksi idempotency key")
+		return model.Transaksi{}, err
+	}
+	return transaksi, nil
+}
+
+// FindDipicuBelumDikoreksi mengambil transaksi biaya atau pajak bunga yang dipicu
+// transaksiPemicuID dan belum dikoreksi, termasuk tunggakan biaya yang tertagih belakangan
+func (r *transaksiRepository) FindDipicuBelumDikoreksi(transaksiPemicuID int) ([]model.Transaksi, error) {
+	var transaksi []model.Transaksi
+	err := r.db.Where("transaksi_pemicu_id = ? AND jenis_transaksi IN ?", transaksiPemicuID, []string{model.JenisBiaya, model.JenisPajak}).
+		Where("NOT EXISTS (SELECT 1 FROM transaksi koreksi WHERE koreksi.transaksi_asal_id = transaksi.id)").
+		Order("id ASC").
+		Find(&transaksi).Error
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"transaksi_pemicu_id": transaksiPemicuID,
+			"error":               err,
+			"action":              "FindDipicuBelumDikoreksi",
+			"layer":               "repository",
+		}).Error("Gagal mencari transaksi yang dipicu")
+		return nil, err
+	}
+	return transaksi, nil
+}
+
+// RekapHarian menjumlahkan nominal dan menghitung transaksi rekening dengan jenis tertentu
+// pada rentang [dari, sampai). Transaksi yang sudah dikoreksi tidak dihitung.
+func (r *transaksiRepository) RekapHarian(rekeningID int, jenis []string, dari, sampai time.Time) (model.RekapHarian, error) {
+	var rekap model.RekapHarian
+	err := r.db.Model(&model.Transaksi{}).
+		Select("COALESCE(SUM(nominal), 0) AS total, COUNT(*) AS jumlah").
+		Where("rekening_id = ? AND jenis_transaksi IN ? AND created_at >= ? AND created_at < ?", rekeningID, jenis, dari, sampai).
+		Where("NOT EXISTS (SELECT 1 FROM transaksi koreksi WHERE koreksi.transaksi_asal_id = transaksi.id)").
+		Scan(&rekap).Error
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"rekening_id": rekeningID,
+			"jenis":       jenis,
+			"error":       err,
+			"action":      "RekapHarian",
+			"layer":       "repository",
+		}).Error("Gagal menghitung rekap transaksi harian")
+		return model.RekapHarian{}, err
+	}
+	return rekap, nil
+}
+
+// SaldoDariTransaksi menghitung ulang saldo rekening dari seluruh baris transaksinya
+func (r *transaksiRepository) SaldoDariTransaksi(rekeningID int) (model.Money, error) {
+	var saldo model.Money
+	err := r.db.Model(&model.Transaksi{}).
+		Select("COALESCE(SUM(CASE WHEN jenis_transaksi IN ? THEN -nominal ELSE nominal END), 0)", model.JenisDebit()).
+		Where("rekening_id = ?", rekeningID).
+		Scan(&saldo).Error
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"rekening_id": rekeningID,
+			"error":       err,
+			"action":      "SaldoDariTransaksi",
+			"layer":       "repository",
+		}).Error("Gagal menghitung saldo dari transaksi")
+		return 0, err
+	}
+	return saldo, nil
+}
+
+// WaktuTransaksiPertama mengembalikan created_at transaksi paling awal, atau waktu nol jika
+// belum ada transaksi sama sekali
+func (r *transaksiRepository) WaktuTransaksiPertama() (time.Time, error) {
+	var pertama sql.NullTime
+	err := r.db.Model(&model.Transaksi{}).Select("MIN(created_at)").Row().Scan(&pertama)
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"error":  err,
+			"action": "WaktuTransaksiPertama",
+			"layer":  "repository",
+		}).Error("Gagal membaca waktu transaksi pertama")
+		return time.Time{}, err
+	}
+	return pertama.Time, nil
+}
+
+// SaldoHarian menghitung saldo rekening per akhir rentang [dari, sampai) beserta total debit,
+// total kredit dan jumlah transaksinya di dalam rentang tersebut
+func (r *transaksiRepository) SaldoHarian(rekeningID int, dari, sampai time.Time) (model.SaldoHarian, error) {
+	var saldo model.SaldoHarian
+	jenisDebit := model.JenisDebit()
+	err := r.db.Model(&model.Transaksi{}).
+		Select(`COALESCE(SUM(CASE WHEN jenis_transaksi IN ? THEN -nominal ELSE nominal END), 0) AS saldo_akhir,
+			COALESCE(SUM(CASE WHEN created_at >= ? AND jenis_transaksi IN ? THEN nominal END), 0) AS total_debit,
+			COALESCE(SUM(CASE WHEN created_at >= ? AND jenis_transaksi NOT IN ? THEN nominal END), 0) AS total_kredit,
+			COUNT(*) FILTER (WHERE created_at >= ?) AS jumlah_transaksi`,
+			jenisDebit, dari, jenisDebit, dari, jenisDebit, dari).
+		Where("rekening_id = ? AND created_at < ?", rekeningID, sampai).
+		Scan(&saldo).Error
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"rekening_id": rekeningID,
+			"dari":        dari,
+			"sampai":      sampai,
+			"error":       err,
+			"action":      "SaldoHarian",
+			"layer":       "repository",
+		}).Error("Gagal menghitung saldo harian")
+		return model.SaldoHarian{}, err
+	}
+	return saldo, nil
+}
+
+// TotalPerJenis menjumlahkan transaksi semua rekening per jenis pada rentang [dari, sampai)
+func (r *transaksiRepository) TotalPerJenis(dari, sampai time.Time) ([]model.TutupHariTotal, error) {
+	var total []model.TutupHariTotal
+	err := r.db.Model(&model.Transaksi{}).
+		Select("jenis_transaksi, COUNT(*) AS jumlah_transaksi, COALESCE(SUM(nominal), 0) AS total_nominal").
+		Where("created_at >= ? AND created_at < ?", dari, sampai).
+		Group("jenis_transaksi").
+		Order("jenis_transaksi ASC").
+		Scan(&total).Error
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"dari":   dari,
+			"sampai": sampai,
+			"error":  err,
+			"action": "TotalPerJenis",
+			"layer":  "repository",
+		}).Error("Gagal menghitung total transaksi per jenis")
+		return nil, err
+	}
+	return total, nil
+}
+
 func NewTransaksiRepository(db *gorm.DB) TransaksiRepository {
 	return &transaksiRepository{db}
 }
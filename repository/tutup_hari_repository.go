@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// formatTanggal dipakai untuk membandingkan kolom DATE tanpa terpengaruh zona waktu
+const formatTanggal = "2006-01-02"
+
+type TutupHariRepository interface {
+	Create(tutupHari model.TutupHari) error
+	Selesai(tutupHari model.TutupHari) error
+	FindByTanggal(tanggal time.Time) (model.TutupHari, error)
+	FindTerakhir() (model.TutupHari, error)
+	CreateSaldoHarian(saldo model.SaldoHarian) error
+	FindSaldoHarian(tanggal time.Time, afterRekeningID int, limit int) ([]model.SaldoHarian, error)
+	FindSaldoHarianTerakhir(rekeningID int, dari, sampai time.Time) (model.SaldoHarian, error)
+	MaxRekeningID(tanggal time.Time) (int, error)
+	CountSaldoHarian(tanggal time.Time) (int, error)
+	SimpanTotal(total []model.TutupHariTotal) error
+}
+
+type tutupHariRepository struct {
+	db *gorm.DB
+}
+
+// Create mencatat tanggal buku sebagai sedang ditutup. Jika tanggal sudah tercatat
+// (misalnya dua proses berjalan bersamaan), baris yang ada dibiarkan.
+func (r *tutupHariRepository) Create(tutupHari model.TutupHari) error {
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&tutupHari).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"tanggal": tutupHari.Tanggal.Format(formatTanggal),
+			"action":  "create tutup hari",
+			"layer":   "repository",
+		}).Error("Gagal mencatat tutup hari")
+	}
+	return err
+}
+
+func (r *tutupHariRepository) Selesai(tutupHari model.TutupHari) error {
+	err := r.db.Model(&model.TutupHari{}).Where("tanggal = ?", tutupHari.Tanggal.Format(formatTanggal)).Updates(map[string]interface{}{
+		"status":          model.StatusTutupHariSelesai,
+		"jumlah_rekening": tutupHari.JumlahRekening,
+		"selesai_at":      tutupHari.SelesaiAt,
+	}).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"tanggal": tutupHari.Tanggal.Format(formatTanggal),
+			"action":  "Selesai",
+			"layer":   "repository",
+		}).Error("Gagal menyelesaikan tutup hari")
+	}
+	return err
+}
+
+// FindByTanggal mengambil tutup hari beserta total per jenis transaksinya
+func (r *tutupHariRepository) FindByTanggal(tanggal time.Time) (model.TutupHari, error) {
+	var tutupHari model.TutupHari
+	err := r.db.Preload("Total", func(db *gorm.DB) *gorm.DB {
+		return db.Order("jenis_transaksi ASC")
+	}).Where("tanggal = ?", tanggal.Format(formatTanggal)).First(&tutupHari).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.TutupHari{}, nil
+	}
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"tanggal": tanggal.Format(formatTanggal),
+			"action":  "FindByTanggal",
+			"layer":   "repository",
+		}).Error("Gagal mengambil tutup hari")
+		return model.TutupHari{}, err
+	}
+	return tutupHari, nil
+}
+
+// FindTerakhir mengambil tanggal buku paling akhir yang sudah atau sedang ditutup
+func (r *tutupHariRepository) FindTerakhir() (model.TutupHari, error) {
+	var tutupHari model.TutupHari
+	err := r.db.Order("tanggal DESC").First(&tutupHari).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.TutupHari{}, nil
+	}
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"action": "FindTerakhir",
+			"layer":  "repository",
+		}).Error("Gagal mengambil tutup hari terakhir")
+		return model.TutupHari{}, err
+	}
+	return tutupHari, nil
+}
+
+// CreateSaldoHarian menyimpan snapshot saldo. Snapshot yang sudah ada tidak ditimpa,
+// sehingga tutup hari yang diulang tidak menggandakan data.
+func (r *tutupHariRepository) CreateSaldoHarian(saldo model.SaldoHarian) error {
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&saldo).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"tanggal":     saldo.Tanggal.Format(formatTanggal),
+			"no_rekening": saldo.NoRekening,
+			"action":      "CreateSaldoHarian",
+			"layer":       "repository",
+		}).Error("Gagal menyimpan saldo harian")
+	}
+	return err
+}
+
+// FindSaldoHarian membaca per halaman snapshot saldo semua rekening pada satu tanggal buku
+func (r *tutupHariRepository) FindSaldoHarian(tanggal time.Time, afterRekeningID int, limit int) ([]model.SaldoHarian, error) {
+	var saldo []model.SaldoHarian
+	err := r.db.Where("tanggal = ? AND rekening_id > ?", tanggal.Format(formatTanggal), afterRekeningID).
+		Order("rekening_id ASC").
+		Limit(limit).
+		Find(&saldo).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"tanggal": tanggal.Format(formatTanggal),
+			"action":  "FindSaldoHarian",
+			"layer":   "repository",
+		}).Error("Gagal membaca saldo harian")
+		return nil, err
+	}
+	return saldo, nil
+}
+
+// FindSaldoHarianTerakhir mengambil snapshot terakhir rekening pada rentang [dari, sampai)
+func (r *tutupHariRepository) FindSaldoHarianTerakhir(rekeningID int, dari, sampai time.Time) (model.SaldoHarian, error) {
+	var saldo model.SaldoHarian
+	err := r.db.Where("rekening_id = ? AND tanggal >= ? AND tanggal < ?", rekeningID, dari.Format(formatTanggal), sampai.Format(formatTanggal)).
+		Order("tanggal DESC").
+		First(&saldo).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.SaldoHarian{}, nil
+	}
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"rekening_id": rekeningID,
+			"dari":        dari.Format(formatTanggal),
+			"action":      "FindSaldoHarianTerakhir",
+			"layer":       "repository",
+		}).Error("Gagal membaca saldo harian terakhir")
+		return model.SaldoHarian{}, err
+	}
+	return saldo, nil
+}
+
+// MaxRekeningID mengembalikan id rekening terbesar yang sudah punya snapshot pada tanggal
+// tersebut, dipakai untuk melanjutkan tutup hari yang terhenti
+func (r *tutupHariRepository) MaxRekeningID(tanggal time.Time) (int, error) {
+	var maxID int
+	err := r.db.Model(&model.SaldoHarian{}).
+		Select("COALESCE(MAX(rekening_id), 0)").
+		Where("tanggal = ?", tanggal.Format(formatTanggal)).
+		Scan(&maxID).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"tanggal": tanggal.Format(formatTanggal),
+			"action":  "MaxRekeningID",
+			"layer":   "repository",
+		}).Error("Gagal membaca snapshot saldo terakhir")
+		return 0, err
+	}
+	return maxID, nil
+}
+
+func (r *tutupHariRepository) CountSaldoHarian(tanggal time.Time) (int, error) {
+	var jumlah int64
+	err := r.db.Model(&model.SaldoHarian{}).Where("tanggal = ?", tanggal.Format(formatTanggal)).Count(&jumlah).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"tanggal": tanggal.Format(formatTanggal),
+			"action":  "CountSaldoHarian",
+			"layer":   "repository",
+		}).Error("Gagal menghitung snapshot saldo")
+		return 0, err
+	}
+	return int(jumlah), nil
+}
+
+// SimpanTotal menyimpan total per jenis transaksi, menimpa hasil dari percobaan sebelumnya
+func (r *tutupHariRepository) SimpanTotal(total []model.TutupHariTotal) error {
+	if len(total) == 0 {
+		return nil
+	}
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tanggal"}, {Name: "jenis_transaksi"}},
+		DoUpdates: clause.AssignmentColumns([]string{"jumlah_transaksi", "total_nominal"}),
+	}).Create(&total).Error
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"tanggal": total[0].Tanggal.Format(formatTanggal),
+			"action":  "SimpanTotal",
+			"layer":   "repository",
+		}).Error("Gagal menyimpan total tutup hari")
+	}
+	return err
+}
+
+func NewTutupHariRepository(db *gorm.DB) TutupHariRepository {
+	return &tutupHariRepository{db}
+}
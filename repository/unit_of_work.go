@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+)
+
+// Repositories berisi repository yang terikat ke satu transaksi database yang sama
+type Repositories struct {
+	NasabahRepository        NasabahRepository
+	RekeningRepository       RekeningRepository
+	TransaksiRepository      TransaksiRepository
+	AuditRepository          AuditRepository
+	ProdukRepository         ProdukRepository
+	NasabahRiwayatRepository NasabahRiwayatRepository
+	LimitRepository          LimitRepository
+	BukuBesarRepository      BukuBesarRepository
+	RekonsiliasiRepository   RekonsiliasiRepository
+	TutupHariRepository      TutupHariRepository
+	BungaRepository          BungaRepository
+	BiayaRepository          BiayaRepository
+}
+
+// UnitOfWork menjalankan beberapa operasi repository dalam satu transaksi database.
+// Jika fn mengembalikan error, seluruh perubahan di dalamnya di-rollback.
+type UnitOfWork interface {
+	Do(fn func(repos Repositories) error) error
+}
+
+type unitOfWork struct {
+	db *gorm.DB
+}
+
+func (u *unitOfWork) Do(fn func(repos Repositories) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(newRepositories(tx))
+	})
+}
+
+func newRepositories(db *gorm.DB) Repositories {
+	return Repositories{
+		NasabahRepository:        NewNasabahRepository(db),
+		RekeningRepository:       NewRekeningRepository(db),
+		TransaksiRepository:      NewTransaksiRepository(db),
+		AuditRepository:          NewAuditRepository(db),
+		ProdukRepository:         NewProdukRepository(db),
+		NasabahRiwayatRepository: NewNasabahRiwayatRepository(db),
+		LimitRepository:          NewLimitRepository(db),
+		BukuBesarRepository:      NewBukuBesarRepository(db),
+		RekonsiliasiRepository:   NewRekonsiliasiRepository(db),
+		TutupHariRepository:      NewTutupHariRepository(db),
+		BungaRepository:          NewBungaRepository(db),
+		BiayaRepository:          NewBiayaRepository(db),
+	}
+}
+
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return &unitOfWork{db}
+}
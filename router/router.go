@@ -5,13 +5,43 @@ import (
 	"github.com/sferawann/go-bank-api/controller"
 )
 
-func NewRouter(e *echo.Echo, allController controller.AllController) {
+func NewRouter(e *echo.Echo, allController controller.AllController, auth echo.MiddlewareFunc, admin echo.MiddlewareFunc, idempotency echo.MiddlewareFunc) {
 
 	api := e.Group("/go-bank-api")
 
-	api.POST("/daftar", allController.Create)
-	api.POST("/tabung", allController.Tabung)
-	api.POST("/tarik", allController.Tarik)
-	api.GET("/saldo/:no_rekening", allController.GetSaldo)
+	api.POST("/daftar", allController.Create, idempotency)
+	api.POST("/login", allController.Login)
+
+	// auth dipasang sebelum idempotency agar Idempotency-Key dipisah per nasabah
+	nasabah := api.Group("", auth)
+	nasabah.POST("/tabung", allController.Tabung, idempotency)
+	nasabah.POST("/tarik", allController.Tarik, idempotency)
+	nasabah.POST("/transfer", allController.Transfer, idempotency)
+	nasabah.GET("/saldo/:no_rekening", allController.GetSaldo)
+	nasabah.GET("/mutasi/:no_rekening", allController.Mutasi)
+	nasabah.POST("/rekening", allController.BukaRekening, idempotency)
+	nasabah.GET("/nasabah/:id", allController.GetNasabah)
+	nasabah.PATCH("/nasabah/:id", allController.UbahProfil)
+	nasabah.GET("/nasabah/:id/riwayat", allController.RiwayatNasabah)
+	nasabah.PUT("/nasabah/:id/kyc", allController.AjukanKYC)
+	nasabah.GET("/nasabah/:id/rekening", allController.ListRekening)
+	nasabah.PUT("/pin", allController.UbahPin)
+	nasabah.POST("/pin/reset", allController.ResetPin)
+
+	adminAPI := api.Group("/admin", admin)
+	adminAPI.PATCH("/rekening/:no_rekening/status", allController.UbahStatusRekening)
+	adminAPI.GET("/nasabah", allController.CariNasabah)
+	adminAPI.GET("/kyc", allController.AntreanKYC)
+	adminAPI.POST("/nasabah/:id/kyc/setujui", allController.SetujuiKYC)
+	adminAPI.POST("/nasabah/:id/kyc/tolak", allController.TolakKYC)
+	adminAPI.POST("/transaksi/:id/reversal", allController.Reversal)
+	adminAPI.POST("/rekonsiliasi", allController.JalankanRekonsiliasi)
+	adminAPI.GET("/rekonsiliasi", allController.ListRekonsiliasi)
+	adminAPI.GET("/rekonsiliasi/:id", allController.GetRekonsiliasi)
+	adminAPI.POST("/tutup-hari", allController.TutupHari)
+	adminAPI.GET("/tutup-hari/:tanggal", allController.GetTutupHari)
+	adminAPI.POST("/bunga/proses", allController.ProsesBunga)
+	adminAPI.POST("/biaya/admin-bulanan", allController.TagihBiayaAdmin)
+	adminAPI.GET("/rekening/:no_rekening/tunggakan", allController.ListTunggakan)
 
 }
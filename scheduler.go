@@ -0,0 +1,30 @@
+package main
+
+import (
+	"time"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/usecase"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// jadwalRekonsiliasi menjalankan rekonsiliasi saldo setiap interval selama aplikasi hidup
+func jadwalRekonsiliasi(rekonsiliasi usecase.RekonsiliasiUsecase, interval time.Duration, bekukan bool) {
+	utils.Log.WithFields(logrus.Fields{
+		"interval": interval.String(),
+		"bekukan":  bekukan,
+		"layer":    "scheduler",
+	}).Info("Rekonsiliasi terjadwal diaktifkan")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if _, err := rekonsiliasi.Jalankan(model.PemicuJadwal, bekukan); err != nil {
+			utils.Log.WithError(err).WithFields(logrus.Fields{
+				"action": "jadwalRekonsiliasi",
+				"layer":  "scheduler",
+			}).Error("Rekonsiliasi terjadwal gagal")
+		}
+	}
+}
@@ -1,9 +1,6 @@
 package usecase
 
 import (
-	"errors"
-	"math"
-
 	"github.com/sferawann/go-bank-api/model"
 	"github.com/sferawann/go-bank-api/repository"
 	"github.com/sferawann/go-bank-api/utils"
@@ -13,16 +10,38 @@ import (
 type AllUsecase interface {
 	Create(newNasabah model.Nasabah) (model.Nasabah, error)
 	FindByNasabahID(nasabahID int) (model.Rekening, error)
+	FindAllRekeningByNasabahID(nasabahID int) ([]model.Rekening, error)
+	BukaRekening(nasabahID int, bukaRekening model.BukaRekening) (model.Rekening, error)
 	FindByNoREK(noREK string) (model.Rekening, error)
 	FindByRekeningID(rekeningID int) (model.Transaksi, error)
-	Tarik(newTarik model.Transaksi) (model.Transaksi, error)
+	Tarik(newTarik model.Transaksi) (model.HasilTransaksi, error)
 	Tabung(newTabung model.Transaksi) (model.Transaksi, error)
+	Transfer(newTransfer model.Transfer) (model.HasilTransaksi, error)
+	Mutasi(filter model.MutasiFilter) (model.Mutasi, error)
+	Login(login model.Login) (model.Token, error)
+	UbahPin(nasabahID int, ubah model.UbahPin) error
+	ResetPin(nasabahID int, reset model.ResetPin) error
+	UbahStatusRekening(noREK string, ubah model.UbahStatusRekening, operator string) (model.Rekening, error)
+	FindNasabahByID(nasabahID int) (model.Nasabah, error)
+	FindNasabahByNIK(nik string) (model.Nasabah, error)
+	UbahProfil(nasabahID int, ubah model.UbahProfil, diubahOleh string) (model.Nasabah, error)
+	RiwayatNasabah(nasabahID int) ([]model.NasabahRiwayat, error)
+	AjukanKYC(nasabahID int, ajuan model.AjukanKYC) (model.Nasabah, error)
+	VerifikasiKYC(nasabahID int, setuju bool, verifikasi model.VerifikasiKYC, operator string) (model.Nasabah, error)
+	AntreanKYC() ([]model.Nasabah, error)
+	Reversal(transaksiID int, reversal model.Reversal, operator string) ([]model.Transaksi, error)
 }
 
 type allUsecase struct {
-	NasabahRepository   repository.NasabahRepository
-	RekeningRepository  repository.RekeningRepository
-	TransaksiRepository repository.TransaksiRepository
+	NasabahRepository        repository.NasabahRepository
+	NasabahRiwayatRepository repository.NasabahRiwayatRepository
+	RekeningRepository       repository.RekeningRepository
+	TransaksiRepository      repository.TransaksiRepository
+	UnitOfWork               repository.UnitOfWork
+	TokenManager             *utils.TokenManager
+	NoRekGenerator           *utils.NoRekGenerator
+	PinMaxGagal              int
+	Clock                    utils.Clock
 }
 
 func (u *allUsecase) Create(NewNasabah model.Nasabah) (model.Nasabah, error) {
@@ -34,91 +53,66 @@ func (u *allUsecase) Create(NewNasabah model.Nasabah) (model.Nasabah, error) {
 		"layer":  "allUsecase",
 	}).Info("menerima permintaan pembuatan nasabah")
 
-	utils.Log.WithFields(logrus.Fields{
-		"nik":    NewNasabah.NIK,
-		"action": "FindByNIK",
-		"layer":  "allUsecase",
-	}).Info("Memeriksa ketersediaan nik")
-	findNIK, err := u.NasabahRepository.FindByNIK(NewNasabah.NIK)
-	if err != nil {
-		utils.Log.WithError(err).WithFields(logrus.Fields{
-			"nik":    NewNasabah.NIK,
-			"action": "FindByNIK",
-			"layer":  "allUsecase",
-		}).Error("Gagal mencari nasabah berdasarkan nik")
+	if err := validateNasabah(&NewNasabah, u.Clock.Now()); err != nil {
 		return model.Nasabah{}, err
 	}
-	if findNIK.ID != 0 {
+
+	passwordHash, err := hashPassword(NewNasabah.Password)
+	if err != nil {
 		utils.Log.WithError(err).WithFields(logrus.Fields{
 			"nik":    NewNasabah.NIK,
-			"action": "validasi",
+			"action": "hash password",
 			"layer":  "allUsecase",
-		}).Error("nik sudah digunakan")
-		return model.Nasabah{}, errors.New("nik sudah digunakan")
+		}).Warn("Password tidak valid")
+		return model.Nasabah{}, err
 	}
+	NewNasabah.PasswordHash = passwordHash
+	NewNasabah.Password = ""
 
-	utils.Log.WithFields(logrus.Fields{
-		"nik":    NewNasabah.NoHP,
-		"action": "FindByNoHP",
-		"layer":  "allUsecase",
-	}).Info("Memeriksa ketersediaan No HP")
-	findNOHP, err := u.NasabahRepository.FindByNoHP(NewNasabah.NoHP)
+	pinHash, err := hashPin(NewNasabah.Pin)
 	if err != nil {
 		utils.Log.WithError(err).WithFields(logrus.Fields{
-			"nik":    NewNasabah.NoHP,
-			"action": "FindByNoHP",
+			"nik":    NewNasabah.NIK,
+			"action": "hash pin",
 			"layer":  "allUsecase",
-		}).Error("Gagal mencari nasabah berdasarkan No HP")
+		}).Warn("PIN tidak valid")
 		return model.Nasabah{}, err
 	}
-	if findNOHP.ID != 0 {
+	NewNasabah.PinHash = pinHash
+	NewNasabah.Pin = ""
+
+	// nasabah dan rekening pertamanya dibuat dalam satu transaksi database sehingga tidak
+	// pernah ada nasabah tanpa rekening. Jika nomor rekening bentrok dengan insert paralel,
+	// seluruh transaksi diulang dengan nomor baru.
+	var (
+		createdNasabah model.Nasabah
+		rekening       model.Rekening
+	)
+	for attempt := 1; ; attempt++ {
+		err = u.UnitOfWork.Do(func(repos repository.Repositories) error {
+			var err error
+			createdNasabah, rekening, err = u.onboard(repos, NewNasabah)
+			return err
+		})
+		if !repository.IsDuplicate(err, repository.ConstraintRekeningNoREK) || attempt == maxGenerateNoRekAttempts {
+			break
+		}
 		utils.Log.WithError(err).WithFields(logrus.Fields{
-			"nik":    NewNasabah.NoHP,
-			"action": "validasi",
-			"layer":  "allUsecase",
-		}).Error("no hp sudah digunakan")
-		return model.Nasabah{}, errors.New("no hp sudah digunakan")
+			"nik":       NewNasabah.NIK,
+			"percobaan": attempt,
+			"action":    "create",
+			"layer":     "allUsecase",
+		}).Warn("Nomor rekening bentrok saat insert, mengulang pendaftaran")
 	}
-
-	utils.Log.WithFields(logrus.Fields{
-		"nama":   NewNasabah.Nama,
-		"nik":    NewNasabah.NIK,
-		"no_hp":  NewNasabah.NoHP,
-		"action": "create",
-		"layer":  "allUsecase",
-	}).Info("Membuat nasabah baru melalui repository")
-	createdNasabah, err := u.NasabahRepository.Create(NewNasabah)
 	if err != nil {
+		err = translateUniqueViolation(err)
 		utils.Log.WithError(err).WithFields(logrus.Fields{
 			"nama":   NewNasabah.Nama,
 			"nik":    NewNasabah.NIK,
 			"no_hp":  NewNasabah.NoHP,
 			"action": "create",
 			"layer":  "allUsecase",
-		}).Error("Gagal membuat nasabah melalui repository")
-		return model.Nasabah{}, err
-	}
-
-	noRek := utils.GenerateNoRek()
-	rekening := model.Rekening{
-		NasabahID:  createdNasabah.ID,
-		NoRekening: noRek,
-	}
-
-	utils.Log.WithFields(logrus.Fields{
-		"nasabah_id":  createdNasabah.ID,
-		"no_rekening": noRek,
-		"action":      "create",
-		"layer":       "allUsecase",
-	}).Info("Membuat rekening untuk nasabah")
-	_, err = u.RekeningRepository.Create(rekening)
-	if err != nil {
-		utils.Log.WithError(err).WithFields(logrus.Fields{
-			"nasabah_id":  createdNasabah.ID,
-			"no_rekening": noRek,
-			"action":      "create",
-			"layer":       "allUsecase",
-		}).Error("Gagal membuat rekening")
+		}).Error("Gagal membuat nasabah dan rekening")
 		return model.Nasabah{}, err
 	}
 
@@ -127,7 +121,7 @@ func (u *allUsecase) Create(NewNasabah model.Nasabah) (model.Nasabah, error) {
 		"nama":        createdNasabah.Nama,
 		"nik":         createdNasabah.NIK,
 		"no_hp":       createdNasabah.NoHP,
-		"no_rekening": noRek,
+		"no_rekening": rekening.NoRekening,
 		"action":      "create",
 		"layer":       "allUsecase",
 	}).Info("Pembuatan nasabah dan rekening berhasil")
@@ -188,6 +182,9 @@ func (u *allUsecase) FindByNoREK(noREK string) (model.Rekening, error) {
 		"action":      "FindByNoREK",
 		"layer":       "allUsecase",
 	}).Info("Mencari rekening berdasarkan Nomor Rekening")
+	if err := validateNoRek(noREK); err != nil {
+		return model.Rekening{}, err
+	}
 	FindNoREK, err := u.RekeningRepository.FindByNoREK(noREK)
 	if err != nil {
 		utils.Log.WithFields(logrus.Fields{
@@ -206,7 +203,7 @@ func (u *allUsecase) FindByNoREK(noREK string) (model.Rekening, error) {
 	return FindNoREK, nil
 }
 
-func (u *allUsecase) Tarik(newTarik model.Transaksi) (model.Transaksi, error) {
+func (u *allUsecase) Tarik(newTarik model.Transaksi) (model.HasilTransaksi, error) {
 	utils.Log.WithFields(logrus.Fields{
 		"no_rekening": newTarik.Rekening.NoRekening,
 		"nominal":     newTarik.Nominal,
@@ -214,24 +211,17 @@ func (u *allUsecase) Tarik(newTarik model.Transaksi) (model.Transaksi, error) {
 		"layer":       "allUsecase",
 	}).Info("menerima permintaan pembuatan transaksi tarik")
 
-	rekening, err := u.RekeningRepository.FindByNoREK(newTarik.Rekening.NoRekening)
-	if err != nil || rekening.ID == 0 {
-		utils.Log.WithFields(logrus.Fields{
-			"no_rekening": newTarik.Rekening.NoRekening,
-			"error":       err,
-			"action":      "FindByNoREK",
-			"layer":       "allUsecase",
-		}).Warn("Rekening tidak ditemukan")
-		return model.Transaksi{}, errors.New("rekening tidak ditemukan")
+	if err := validateNoRek(newTarik.Rekening.NoRekening); err != nil {
+		return model.HasilTransaksi{}, err
 	}
 
-	if newTarik.Nominal != math.Floor(newTarik.Nominal) {
+	if !newTarik.Nominal.IsWhole() {
 		utils.Log.WithFields(logrus.Fields{
 			"nominal": newTarik.Nominal,
 			"action":  "validasi nominal bulat",
 			"layer":   "allUsecase",
 		}).Warn("Nominal tidak boleh desimal")
-		return model.Transaksi{}, errors.New("nominal harus bilangan bulat")
+		return model.HasilTransaksi{}, ErrNominalNotWhole
 	}
 
 	if newTarik.Nominal <= 0 {
@@ -240,60 +230,118 @@ func (u *allUsecase) Tarik(newTarik model.Transaksi) (model.Transaksi, error) {
 			"action":  "validasi nominal tarik",
 			"layer":   "allUsecase",
 		}).Warn("Nominal harus lebih dari 0")
-		return model.Transaksi{}, errors.New("nominal harus lebih dari 0")
+		return model.HasilTransaksi{}, ErrNominalNotPositive
 	}
 
-	if rekening.Saldo < newTarik.Nominal {
-		utils.Log.WithFields(logrus.Fields{
-			"saldo":   rekening.Saldo,
-			"nominal": newTarik.Nominal,
-			"action":  "saldo kurang dari nominal",
-			"layer":   "allUsecase",
-		}).Warn("Saldo tidak mencukupi untuk melakukan transaksi tarik")
-		return model.Transaksi{}, errors.New("saldo tidak mencukupi")
-	}
-
-	rekening.Saldo -= newTarik.Nominal
-
-	_, err = u.RekeningRepository.UpdateSaldo(rekening)
+	pemilik, err := u.RekeningRepository.FindByNoREK(newTarik.Rekening.NoRekening)
 	if err != nil {
-		utils.Log.WithFields(logrus.Fields{
-			"rekening_id": rekening.ID,
-			"error":       err,
-			"action":      "update saldo",
-			"layer":       "allUsecase",
-		}).Error("Gagal update saldo setelah pengurangan")
-		return model.Transaksi{}, err
+		return model.HasilTransaksi{}, err
 	}
-	newTransaksi := model.Transaksi{
-		RekeningID:     rekening.ID,
-		JenisTransaksi: "tarik",
-		Nominal:        newTarik.Nominal,
+	if pemilik.ID == 0 {
+		return model.HasilTransaksi{}, ErrAccountNotFound
 	}
-
-	transaksiTarik, err := u.TransaksiRepository.Tabung(newTransaksi)
-	if err != nil {
-		utils.Log.WithFields(logrus.Fields{
+	if err := u.verifyPin(pemilik.NasabahID, newTarik.Pin); err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
 			"no_rekening": newTarik.Rekening.NoRekening,
-			"nominal":     newTarik.Nominal,
-			"error":       err,
-			"action":      "Tarik",
+			"action":      "verifikasi pin",
 			"layer":       "allUsecase",
-		}).Error("Gagal mencatat transaksi tarik, rollback saldo")
-		rekening.Saldo += newTransaksi.Nominal
-		u.RekeningRepository.UpdateSaldo(rekening)
-		return model.Transaksi{}, err
+		}).Warn("Verifikasi PIN transaksi tarik gagal")
+		return model.HasilTransaksi{}, err
+	}
+
+	// baris rekening dikunci sampai commit agar dua penarikan paralel tidak
+	// sama-sama lolos pengecekan saldo
+	var (
+		transaksiTarik model.Transaksi
+		biaya, saldo   model.Money
+	)
+	err = u.UnitOfWork.Do(func(repos repository.Repositories) error {
+		rekening, err := repos.RekeningRepository.FindByNoREKForUpdate(newTarik.Rekening.NoRekening)
+		if err != nil {
+			return err
+		}
+		if rekening.ID == 0 {
+			utils.Log.WithFields(logrus.Fields{
+				"no_rekening": newTarik.Rekening.NoRekening,
+				"action":      "FindByNoREK",
+				"layer":       "allUsecase",
+			}).Warn("Rekening tidak ditemukan")
+			return ErrAccountNotFound
+		}
+
+		sudah, err := transaksiIdempoten(repos, newTarik.IdempotencyKeyID)
+		if err != nil {
+			return err
+		}
+		if sudah.ID != 0 {
+			transaksiTarik, saldo = sudah, rekening.Saldo
+			biaya, err = biayaDipicu(repos, sudah.ID)
+			return err
+		}
+
+		now := u.Clock.Now()
+		if err := checkDebit(repos, rekening, newTarik.Nominal, now); err != nil {
+			return err
+		}
+
+		saldoAwal := rekening.Saldo
+		rekening.Saldo -= newTarik.Nominal
+
+		jurnal, err := postJurnal(repos, "", "tarik tunai rekening "+rekening.NoRekening, []entriJurnal{
+			{Rekening: &rekening, Debit: newTarik.Nominal},
+			{KodeAkun: model.AkunKas, Kredit: newTarik.Nominal},
+		})
+		if err != nil {
+			utils.Log.WithFields(logrus.Fields{
+				"rekening_id": rekening.ID,
+				"error":       err,
+				"action":      "postJurnal",
+				"layer":       "allUsecase",
+			}).Error("Gagal membukukan jurnal tarik")
+			return err
+		}
+
+		transaksiTarik, err = repos.TransaksiRepository.Tarik(model.Transaksi{
+			RekeningID:       rekening.ID,
+			JenisTransaksi:   model.JenisTarik,
+			Nominal:          newTarik.Nominal,
+			SaldoAwal:        saldoAwal,
+			SaldoAkhir:       rekening.Saldo,
+			JurnalID:         &jurnal.ID,
+			IdempotencyKeyID: newTarik.IdempotencyKeyID,
+		})
+		if err != nil {
+			utils.Log.WithFields(logrus.Fields{
+				"no_rekening": newTarik.Rekening.NoRekening,
+				"nominal":     newTarik.Nominal,
+				"error":       err,
+				"action":      "Tarik",
+				"layer":       "allUsecase",
+			}).Error("Gagal mencatat transaksi tarik, rollback saldo")
+			return err
+		}
+		if err := recordSaldo(repos, rekening, transaksiTarik); err != nil {
+			return err
+		}
+		if biaya, err = biayaTarik(repos, &rekening, transaksiTarik, now); err != nil {
+			return err
+		}
+		saldo = rekening.Saldo
+		return nil
+	})
+	if err != nil {
+		return model.HasilTransaksi{}, err
 	}
 
 	utils.Log.WithFields(logrus.Fields{
 		"transaksi_id": transaksiTarik.ID,
-		"rekening_id":  rekening.ID,
+		"rekening_id":  transaksiTarik.RekeningID,
 		"nominal":      transaksiTarik.Nominal,
 		"jenis":        transaksiTarik.JenisTransaksi,
 		"action":       "Tarik",
 		"layer":        "allUsecase",
 	}).Info("Transaksi tarik berhasil dibuat")
-	return transaksiTarik, nil
+	return model.HasilTransaksi{Transaksi: transaksiTarik, Biaya: biaya, Saldo: saldo}, nil
 }
 
 func (u *allUsecase) Tabung(newTabung model.Transaksi) (model.Transaksi, error) {
@@ -303,24 +351,18 @@ func (u *allUsecase) Tabung(newTabung model.Transaksi) (model.Transaksi, error)
 		"action":      "create transaksi tabung",
 		"layer":       "allUsecase",
 	}).Info("menerima permintaan pembuatan transaksi tabung")
-	rekening, err := u.RekeningRepository.FindByNoREK(newTabung.Rekening.NoRekening)
-	if err != nil || rekening.ID == 0 {
-		utils.Log.WithFields(logrus.Fields{
-			"no_rekening": newTabung.Rekening.NoRekening,
-			"error":       err,
-			"action":      "FindByNoREK",
-			"layer":       "allUsecase",
-		}).Warn("Rekening tidak ditemukan")
-		return model.Transaksi{}, errors.New("rekening tidak ditemukan")
+
+	if err := validateNoRek(newTabung.Rekening.NoRekening); err != nil {
+		return model.Transaksi{}, err
 	}
 
-	if newTabung.Nominal != math.Floor(newTabung.Nominal) {
+	if !newTabung.Nominal.IsWhole() {
 		utils.Log.WithFields(logrus.Fields{
 			"nominal": newTabung.Nominal,
 			"action":  "validasi nominal bulat",
 			"layer":   "allUsecase",
 		}).Warn("Nominal tidak boleh desimal")
-		return model.Transaksi{}, errors.New("nominal harus bilangan bulat")
+		return model.Transaksi{}, ErrNominalNotWhole
 	}
 	if newTabung.Nominal <= 0 {
 		utils.Log.WithFields(logrus.Fields{
@@ -328,44 +370,85 @@ func (u *allUsecase) Tabung(newTabung model.Transaksi) (model.Transaksi, error)
 			"action":  "validasi nominal tabung",
 			"layer":   "allUsecase",
 		}).Warn("Nominal harus lebih dari 0")
-		return model.Transaksi{}, errors.New("nominal harus lebih dari 0")
+		return model.Transaksi{}, ErrNominalNotPositive
 	}
 
-	rekening.Saldo += newTabung.Nominal
+	var transaksiTabung model.Transaksi
+	err := u.UnitOfWork.Do(func(repos repository.Repositories) error {
+		rekening, err := repos.RekeningRepository.FindByNoREKForUpdate(newTabung.Rekening.NoRekening)
+		if err != nil {
+			return err
+		}
+		if rekening.ID == 0 {
+			utils.Log.WithFields(logrus.Fields{
+				"no_rekening": newTabung.Rekening.NoRekening,
+				"action":      "FindByNoREK",
+				"layer":       "allUsecase",
+			}).Warn("Rekening tidak ditemukan")
+			return ErrAccountNotFound
+		}
 
-	_, err = u.RekeningRepository.UpdateSaldo(rekening)
-	if err != nil {
-		utils.Log.WithFields(logrus.Fields{
-			"rekening_id": rekening.ID,
-			"error":       err,
-			"action":      "update saldo",
-			"layer":       "allUsecase",
-		}).Error("Gagal update saldo setelah pengurangan")
-		return model.Transaksi{}, err
-	}
-	newTransaksi := model.Transaksi{
-		RekeningID:     rekening.ID,
-		JenisTransaksi: "tabung",
-		Nominal:        newTabung.Nominal,
-	}
+		sudah, err := transaksiIdempoten(repos, newTabung.IdempotencyKeyID)
+		if err != nil {
+			return err
+		}
+		if sudah.ID != 0 {
+			transaksiTabung = sudah
+			return nil
+		}
+
+		if err := checkCredit(rekening); err != nil {
+			return err
+		}
+		if err := checkLimit(repos, rekening, model.ArahKredit, newTabung.Nominal, u.Clock.Now()); err != nil {
+			return err
+		}
+
+		saldoAwal := rekening.Saldo
+		rekening.Saldo += newTabung.Nominal
 
-	transaksiTabung, err := u.TransaksiRepository.Tabung(newTransaksi)
+		jurnal, err := postJurnal(repos, "", "setor tunai rekening "+rekening.NoRekening, []entriJurnal{
+			{KodeAkun: model.AkunKas, Debit: newTabung.Nominal},
+			{Rekening: &rekening, Kredit: newTabung.Nominal},
+		})
+		if err != nil {
+			utils.Log.WithFields(logrus.Fields{
+				"rekening_id": rekening.ID,
+				"error":       err,
+				"action":      "postJurnal",
+				"layer":       "allUsecase",
+			}).Error("Gagal membukukan jurnal tabung")
+			return err
+		}
+
+		transaksiTabung, err = repos.TransaksiRepository.Tabung(model.Transaksi{
+			RekeningID:       rekening.ID,
+			JenisTransaksi:   model.JenisTabung,
+			Nominal:          newTabung.Nominal,
+			SaldoAwal:        saldoAwal,
+			SaldoAkhir:       rekening.Saldo,
+			JurnalID:         &jurnal.ID,
+			IdempotencyKeyID: newTabung.IdempotencyKeyID,
+		})
+		if err != nil {
+			utils.Log.WithFields(logrus.Fields{
+				"no_rekening": newTabung.Rekening.NoRekening,
+				"nominal":     newTabung.Nominal,
+				"error":       err,
+				"action":      "create tabung",
+				"layer":       "allUsecase",
+			}).Error("Gagal mencatat transaksi tabung, rollback saldo")
+			return err
+		}
+		return recordSaldo(repos, rekening, transaksiTabung)
+	})
 	if err != nil {
-		utils.Log.WithFields(logrus.Fields{
-			"no_rekening": newTabung.Rekening.NoRekening,
-			"nominal":     newTabung.Nominal,
-			"error":       err,
-			"action":      "create tabung",
-			"layer":       "allUsecase",
-		}).Error("Gagal mencatat transaksi tabung, rollback saldo")
-		rekening.Saldo -= newTransaksi.Nominal
-		u.RekeningRepository.UpdateSaldo(rekening)
 		return model.Transaksi{}, err
 	}
 
 	utils.Log.WithFields(logrus.Fields{
 		"transaksi_id": transaksiTabung.ID,
-		"rekening_id":  rekening.ID,
+		"rekening_id":  transaksiTabung.RekeningID,
 		"nominal":      transaksiTabung.Nominal,
 		"jenis":        transaksiTabung.JenisTransaksi,
 		"action":       "create tabung",
@@ -374,10 +457,26 @@ func (u *allUsecase) Tabung(newTabung model.Transaksi) (model.Transaksi, error)
 	return transaksiTabung, nil
 }
 
-func NewUsecase(nasabahRepository repository.NasabahRepository, rekeningRepository repository.RekeningRepository, transaksiRepository repository.TransaksiRepository) AllUsecase {
+// transaksiIdempoten mengambil transaksi yang sudah ter-commit untuk Idempotency-Key request
+// ini. Dipanggil setelah rekening dikunci, sehingga retry yang berjalan bersamaan dengan
+// request pertamanya menunggu lalu mendapat transaksi yang sama alih-alih memindahkan uang lagi.
+func transaksiIdempoten(repos repository.Repositories, idempotencyKeyID *int) (model.Transaksi, error) {
+	if idempotencyKeyID == nil {
+		return model.Transaksi{}, nil
+	}
+	return repos.TransaksiRepository.FindByIdempotencyKeyID(*idempotencyKeyID)
+}
+
+func NewUsecase(nasabahRepository repository.NasabahRepository, nasabahRiwayatRepository repository.NasabahRiwayatRepository, rekeningRepository repository.RekeningRepository, transaksiRepository repository.TransaksiRepository, unitOfWork repository.UnitOfWork, tokenManager *utils.TokenManager, noRekGenerator *utils.NoRekGenerator, pinMaxGagal int, clock utils.Clock) AllUsecase {
 	return &allUsecase{
-		NasabahRepository:   nasabahRepository,
-		RekeningRepository:  rekeningRepository,
-		TransaksiRepository: transaksiRepository,
+		NasabahRepository:        nasabahRepository,
+		NasabahRiwayatRepository: nasabahRiwayatRepository,
+		RekeningRepository:       rekeningRepository,
+		TransaksiRepository:      transaksiRepository,
+		UnitOfWork:               unitOfWork,
+		TokenManager:             tokenManager,
+		NoRekGenerator:           noRekGenerator,
+		PinMaxGagal:              pinMaxGagal,
+		Clock:                    clock,
 	}
 }
@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+	"github.com/sferawann/go-bank-api/utils"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	produkUji = "uji_paralel"
+	pinUji    = "123456"
+)
+
+func TestMain(m *testing.M) {
+	utils.SetupLogger()
+	os.Exit(m.Run())
+}
+
+// openTestDB membuka database Postgres yang sudah berisi skema bank-api.sql. Test dilewati
+// jika TEST_DATABASE_DSN tidak diisi.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN tidak diisi, test integrasi database dilewati")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("gagal konek ke database test: %v", err)
+	}
+	return db
+}
+
+// buatRekeningUji membuat nasabah terverifikasi dengan satu rekening produk tanpa biaya,
+// lalu menyetor saldoAwal ke rekening tersebut
+func buatRekeningUji(t *testing.T, u *allUsecase, db *gorm.DB, saldoAwal model.Money) model.Rekening {
+	t.Helper()
+	err := db.Exec(`INSERT INTO produk (kode, nama, saldo_minimum, boleh_tarik) VALUES (?, 'Produk Uji Paralel', 0, TRUE)
+		ON CONFLICT (kode) DO NOTHING`, produkUji).Error
+	if err != nil {
+		t.Fatalf("gagal membuat produk uji: %v", err)
+	}
+
+	pinHash, err := hashPin(pinUji)
+	if err != nil {
+		t.Fatalf("gagal hash pin: %v", err)
+	}
+	unik := time.Now().UnixNano() % 1_000_000_000_000
+
+	var rekening model.Rekening
+	err = u.UnitOfWork.Do(func(repos repository.Repositories) error {
+		nasabah, err := repos.NasabahRepository.Create(model.Nasabah{
+			Nama:         "Nasabah Uji Paralel",
+			NIK:          fmt.Sprintf("9%015d", unik),
+			NoHP:         fmt.Sprintf("+62%012d", unik),
+			PasswordHash: pinHash,
+			PinHash:      pinHash,
+			KYC:          model.KYC{Status: model.KYCVerified},
+		})
+		if err != nil {
+			return err
+		}
+		rekening, err = u.createRekening(repos, nasabah.ID, produkUji)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("gagal membuat rekening uji: %v", err)
+	}
+
+	_, err = u.Tabung(model.Transaksi{Nominal: saldoAwal, Rekening: model.Rekening{NoRekening: rekening.NoRekening}})
+	if err != nil {
+		t.Fatalf("gagal menyetor saldo awal: %v", err)
+	}
+	return rekening
+}
+
+// TestTarikParalel menembakkan penarikan paralel ke satu rekening. Karena baris rekening
+// dikunci FOR UPDATE selama pengecekan saldo, hanya penarikan yang tertutup saldo yang
+// berhasil, sisanya ditolak dengan ErrInsufficientFunds dan saldo tidak pernah negatif.
+func TestTarikParalel(t *testing.T) {
+	db := openTestDB(t)
+	noRekGenerator, err := utils.NewNoRekGenerator("999")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := NewUsecase(
+		repository.NewNasabahRepository(db),
+		repository.NewNasabahRiwayatRepository(db),
+		repository.NewRekeningRepository(db),
+		repository.NewTransaksiRepository(db),
+		repository.NewUnitOfWork(db),
+		nil,
+		noRekGenerator,
+		3,
+		utils.NewSystemClock(),
+	).(*allUsecase)
+
+	const (
+		jumlahTarik   = 20
+		harapBerhasil = 10
+	)
+	nominalTarik := model.Rupiah(100_000)
+	saldoAwal := model.Rupiah(1_000_000)
+	rekening := buatRekeningUji(t, u, db, saldoAwal)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		berhasil int
+		ditolak  int
+		lainnya  []error
+	)
+	mulai := make(chan struct{})
+	for i := 0; i < jumlahTarik; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-mulai
+			_, err := u.Tarik(model.Transaksi{
+				Nominal:  nominalTarik,
+				Pin:      pinUji,
+				Rekening: model.Rekening{NoRekening: rekening.NoRekening},
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				berhasil++
+			case errors.Is(err, ErrInsufficientFunds):
+				ditolak++
+			default:
+				lainnya = append(lainnya, err)
+			}
+		}()
+	}
+	close(mulai)
+	wg.Wait()
+
+	if len(lainnya) > 0 {
+		t.Fatalf("penarikan gagal dengan error tak terduga: %v", lainnya)
+	}
+	if berhasil != harapBerhasil || ditolak != jumlahTarik-harapBerhasil {
+		t.Fatalf("berhasil %d ditolak %d, seharusnya berhasil %d ditolak %d", berhasil, ditolak, harapBerhasil, jumlahTarik-harapBerhasil)
+	}
+
+	akhir, err := u.RekeningRepository.FindByNoREK(rekening.NoRekening)
+	if err != nil {
+		t.Fatal(err)
+	}
+	harapSaldo := saldoAwal - model.Money(berhasil)*nominalTarik
+	if akhir.Saldo != harapSaldo || akhir.Saldo < 0 {
+		t.Fatalf("saldo akhir %s, seharusnya %s", akhir.Saldo, harapSaldo)
+	}
+
+	saldoTransaksi, err := repository.NewTransaksiRepository(db).SaldoDariTransaksi(akhir.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saldoTransaksi != akhir.Saldo {
+		t.Fatalf("saldo dari transaksi %s tidak sama dengan saldo rekening %s", saldoTransaksi, akhir.Saldo)
+	}
+}
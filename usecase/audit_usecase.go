@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"fmt"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const auditVerifyBatch = 1000
+
+// recordAudit menambahkan entri ke rantai audit nasabah. Harus dipanggil di dalam UnitOfWork
+// agar entri ikut di-rollback bersama perubahan yang dicatatnya.
+func recordAudit(repos repository.Repositories, nasabahID int, jenis, keterangan string) error {
+	_, err := repos.AuditRepository.Create(model.AuditEvent{
+		Rantai:     model.RantaiNasabah(nasabahID),
+		NasabahID:  &nasabahID,
+		Jenis:      jenis,
+		Keterangan: keterangan,
+	})
+	return err
+}
+
+// recordSaldo mencatat satu perubahan saldo rekening beserta transaksi penyebabnya di rantai
+// audit rekening tersebut, sehingga transaksi di rekening lain tidak ikut antre
+func recordSaldo(repos repository.Repositories, rekening model.Rekening, transaksi model.Transaksi) error {
+	keterangan := fmt.Sprintf("rekening %s %s %s: saldo %s -> %s, transaksi %d",
+		rekening.NoRekening, transaksi.JenisTransaksi, transaksi.Nominal, transaksi.SaldoAwal, transaksi.SaldoAkhir, transaksi.ID)
+	if transaksi.NoReferensi != "" {
+		keterangan += ", ref " + transaksi.NoReferensi
+	}
+	nasabahID := rekening.NasabahID
+	_, err := repos.AuditRepository.Create(model.AuditEvent{
+		Rantai:     model.RantaiRekening(rekening.ID),
+		NasabahID:  &nasabahID,
+		Jenis:      model.AuditSaldoBerubah,
+		Keterangan: keterangan,
+	})
+	return err
+}
+
+// VerifyAuditChain menelusuri setiap rantai audit_event dari awal dan menghitung ulang setiap
+// hash. Entri yang isinya diubah membuat hash tidak cocok, sedangkan entri yang dihapus atau
+// disisipkan membuat prev_hash tidak menunjuk ke hash entri sebelumnya di rantainya.
+func VerifyAuditChain(auditRepository repository.AuditRepository) (model.AuditVerifikasi, error) {
+	hasil := model.AuditVerifikasi{Valid: true}
+	prevHash := model.GenesisHash
+	rantai := ""
+	lastID := 0
+
+	for {
+		events, err := auditRepository.FindSetelah(rantai, lastID, auditVerifyBatch)
+		if err != nil {
+			return model.AuditVerifikasi{}, err
+		}
+		for _, event := range events {
+			if event.Rantai != rantai {
+				rantai = event.Rantai
+				prevHash = model.GenesisHash
+			}
+			hasil.JumlahEntri++
+			if event.PrevHash != prevHash {
+				hasil.Temuan = append(hasil.Temuan, fmt.Sprintf("entri %d rantai %s: prev_hash tidak cocok dengan hash entri sebelumnya, ada entri yang dihapus atau disisipkan", event.ID, event.Rantai))
+			}
+			if event.ComputeHash() != event.Hash {
+				hasil.Temuan = append(hasil.Temuan, fmt.Sprintf("entri %d rantai %s: hash tidak cocok dengan isi entri, isi entri telah diubah", event.ID, event.Rantai))
+			}
+			prevHash = event.Hash
+			lastID = event.ID
+		}
+		if len(events) < auditVerifyBatch {
+			break
+		}
+	}
+
+	hasil.Valid = len(hasil.Temuan) == 0
+	fields := logrus.Fields{
+		"jumlah_entri":  hasil.JumlahEntri,
+		"jumlah_temuan": len(hasil.Temuan),
+		"action":        "VerifyAuditChain",
+		"layer":         "allUsecase",
+	}
+	if hasil.Valid {
+		utils.Log.WithFields(fields).Info("Rantai audit utuh")
+	} else {
+		utils.Log.WithFields(fields).Error("Rantai audit rusak")
+	}
+	return hasil, nil
+}
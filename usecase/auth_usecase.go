@@ -0,0 +1,66 @@
+package usecase
+
+import (
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const minPasswordLength = 8
+
+func (u *allUsecase) Login(login model.Login) (model.Token, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"no_hp":  login.NoHP,
+		"action": "login",
+		"layer":  "allUsecase",
+	}).Info("menerima permintaan login")
+
+	// no hp yang formatnya salah pasti tidak terdaftar, jadi pesannya disamakan dengan login gagal
+	noHP, err := utils.NormalizeNoHP(login.NoHP)
+	if err != nil {
+		return model.Token{}, ErrInvalidCredentials
+	}
+
+	nasabah, err := u.NasabahRepository.FindByNoHP(noHP)
+	if err != nil {
+		return model.Token{}, err
+	}
+	// pesan error sengaja sama untuk no hp tidak terdaftar maupun password salah
+	if nasabah.ID == 0 || bcrypt.CompareHashAndPassword([]byte(nasabah.PasswordHash), []byte(login.Password)) != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"no_hp":  login.NoHP,
+			"action": "login",
+			"layer":  "allUsecase",
+		}).Warn("Login gagal")
+		return model.Token{}, ErrInvalidCredentials
+	}
+
+	token, expiredAt, err := u.TokenManager.Generate(nasabah.ID)
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"nasabah_id": nasabah.ID,
+			"action":     "login",
+			"layer":      "allUsecase",
+		}).Error("Gagal membuat token")
+		return model.Token{}, err
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"nasabah_id": nasabah.ID,
+		"action":     "login",
+		"layer":      "allUsecase",
+	}).Info("Login berhasil")
+	return model.Token{Token: token, ExpiredAt: expiredAt}, nil
+}
+
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", ErrPasswordTooShort
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
@@ -0,0 +1,388 @@
+package usecase
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const biayaBatch = 500
+
+// BiayaUsecase menagih biaya admin bulanan semua rekening. Biaya tarik dan transfer
+// ditagih langsung di dalam transaksi pemicunya lewat biayaTarik dan biayaTransfer.
+type BiayaUsecase interface {
+	TagihAdminBulanan(periode time.Time) (model.HasilBiayaAdmin, error)
+	PeriodeTerakhir() time.Time
+	FindTunggakan(noREK string) ([]model.TagihanBiaya, error)
+}
+
+type biayaUsecase struct {
+	RekeningRepository repository.RekeningRepository
+	BiayaRepository    repository.BiayaRepository
+	UnitOfWork         repository.UnitOfWork
+	Clock              utils.Clock
+}
+
+// TagihAdminBulanan menagih biaya admin satu periode bulan ke setiap rekening yang sudah
+// dibuka sebelum periode berakhir. Tunggakan lama rekening ditagih lebih dulu. Biaya admin
+// hanya dicatat sekali per rekening per periode, jadi proses ini aman dijalankan ulang.
+func (u *biayaUsecase) TagihAdminBulanan(periode time.Time) (model.HasilBiayaAdmin, error) {
+	dari := time.Date(periode.Year(), periode.Month(), 1, 0, 0, 0, 0, time.Local)
+	sampai := dari.AddDate(0, 1, 0)
+	hasil := model.HasilBiayaAdmin{Periode: dari.Format(formatPeriode)}
+	fields := logrus.Fields{
+		"periode": hasil.Periode,
+		"action":  "TagihAdminBulanan",
+		"layer":   "biayaUsecase",
+	}
+
+	if awalHari(u.Clock.Now()).Before(sampai) {
+		utils.Log.WithFields(fields).Info("Periode biaya admin belum berakhir")
+		return hasil, ErrPeriodeBiayaBerjalan
+	}
+
+	lastID := 0
+	for {
+		rekenings, err := u.RekeningRepository.FindDibukaSebelum(sampai, lastID, biayaBatch)
+		if err != nil {
+			return hasil, err
+		}
+		for _, rekening := range rekenings {
+			tertagih, dikenakan, err := u.tagihAdminRekening(rekening.NoRekening, dari)
+			if err != nil {
+				utils.Log.WithError(err).WithFields(fields).WithField("no_rekening", rekening.NoRekening).Error("Penagihan biaya admin terhenti, jalankan ulang untuk melanjutkan")
+				return hasil, err
+			}
+			if dikenakan {
+				hasil.JumlahRekening++
+			}
+			hasil.TotalTertagih += tertagih
+			lastID = rekening.ID
+		}
+		if len(rekenings) < biayaBatch {
+			break
+		}
+	}
+
+	utils.Log.WithFields(fields).WithField("jumlah_rekening", hasil.JumlahRekening).WithField("total_tertagih", hasil.TotalTertagih).Info("Biaya admin selesai ditagih")
+	return hasil, nil
+}
+
+func (u *biayaUsecase) tagihAdminRekening(noREK string, periode time.Time) (model.Money, bool, error) {
+	var (
+		tertagih  model.Money
+		dikenakan bool
+	)
+	err := u.UnitOfWork.Do(func(repos repository.Repositories) error {
+		tertagih, dikenakan = 0, false
+		rekening, err := repos.RekeningRepository.FindByNoREKForUpdate(noREK)
+		if err != nil {
+			return err
+		}
+		if rekening.Status == model.StatusDitutup {
+			return nil
+		}
+
+		produk, err := repos.ProdukRepository.FindByKode(rekening.KodeProduk)
+		if err != nil {
+			return err
+		}
+
+		tunggakan, err := repos.BiayaRepository.FindTunggakan(rekening.ID)
+		if err != nil {
+			return err
+		}
+		for _, t := range tunggakan {
+			sebelum := t.Tertagih
+			if t, err = tagihBiaya(repos, &rekening, produk, t, model.KebijakanTunggakan); err != nil {
+				return err
+			}
+			tertagih += t.Tertagih - sebelum
+		}
+
+		if produk.BiayaAdminBulanan <= 0 {
+			return nil
+		}
+		sudah, err := repos.BiayaRepository.FindTagihanAdmin(rekening.ID, periode)
+		if err != nil {
+			return err
+		}
+		if sudah.ID != 0 {
+			return nil
+		}
+
+		tagihan, err := tagihBiaya(repos, &rekening, produk, model.TagihanBiaya{
+			RekeningID: rekening.ID,
+			JenisBiaya: model.BiayaAdminBulanan,
+			Periode:    &periode,
+			Nominal:    produk.BiayaAdminBulanan,
+		}, produk.KebijakanBiaya)
+		if err != nil {
+			return err
+		}
+		tertagih += tagihan.Tertagih
+		dikenakan = true
+		return nil
+	})
+	return tertagih, dikenakan, err
+}
+
+// PeriodeTerakhir mengembalikan awal bulan dari periode terakhir yang sudah berakhir
+func (u *biayaUsecase) PeriodeTerakhir() time.Time {
+	now := u.Clock.Now()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local).AddDate(0, -1, 0)
+}
+
+func (u *biayaUsecase) FindTunggakan(noREK string) ([]model.TagihanBiaya, error) {
+	if err := validateNoRek(noREK); err != nil {
+		return nil, err
+	}
+	rekening, err := u.RekeningRepository.FindByNoREK(noREK)
+	if err != nil {
+		return nil, err
+	}
+	if rekening.ID == 0 {
+		return nil, ErrAccountNotFound
+	}
+	return u.BiayaRepository.FindTunggakan(rekening.ID)
+}
+
+// biayaTarik mengenakan biaya tarik jika penarikan ini melebihi jatah gratis bulan dari now.
+// Dipanggil di dalam UnitOfWork yang sama dengan penarikannya, setelah transaksi tarik tercatat.
+func biayaTarik(repos repository.Repositories, rekening *model.Rekening, tarik model.Transaksi, now time.Time) (model.Money, error) {
+	produk, err := repos.ProdukRepository.FindByKode(rekening.KodeProduk)
+	if err != nil {
+		return 0, err
+	}
+	if produk.BiayaTarik <= 0 {
+		return 0, nil
+	}
+
+	awalBulan := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	rekap, err := repos.TransaksiRepository.RekapHarian(rekening.ID, []string{model.JenisTarik}, awalBulan, awalBulan.AddDate(0, 1, 0))
+	if err != nil {
+		return 0, err
+	}
+	if rekap.Jumlah <= produk.GratisTarikPerBulan {
+		return 0, nil
+	}
+
+	tagihan, err := tagihBiaya(repos, rekening, produk, model.TagihanBiaya{
+		RekeningID:        rekening.ID,
+		JenisBiaya:        model.BiayaTarik,
+		TransaksiPemicuID: &tarik.ID,
+		Nominal:           produk.BiayaTarik,
+	}, produk.KebijakanBiaya)
+	return tagihan.Tertagih, err
+}
+
+// biayaTransfer mengenakan biaya transfer ke rekening asal. Dipanggil di dalam UnitOfWork
+// yang sama dengan transfernya, setelah kedua sisi transfer tercatat.
+func biayaTransfer(repos repository.Repositories, asal *model.Rekening, keluar model.Transaksi) (model.Money, error) {
+	produk, err := repos.ProdukRepository.FindByKode(asal.KodeProduk)
+	if err != nil {
+		return 0, err
+	}
+	if produk.BiayaTransfer <= 0 {
+		return 0, nil
+	}
+
+	tagihan, err := tagihBiaya(repos, asal, produk, model.TagihanBiaya{
+		RekeningID:        asal.ID,
+		JenisBiaya:        model.BiayaTransfer,
+		TransaksiPemicuID: &keluar.ID,
+		Nominal:           produk.BiayaTransfer,
+	}, produk.KebijakanBiaya)
+	return tagihan.Tertagih, err
+}
+
+// biayaDipicu menjumlahkan biaya yang sudah tertagih dari transaksi pemicunya, dipakai saat
+// transaksi yang sudah ter-commit dikembalikan ulang ke retry
+func biayaDipicu(repos repository.Repositories, transaksiPemicuID int) (model.Money, error) {
+	dipicu, err := repos.TransaksiRepository.FindDipicuBelumDikoreksi(transaksiPemicuID)
+	if err != nil {
+		return 0, err
+	}
+	var biaya model.Money
+	for _, trx := range dipicu {
+		if trx.JenisTransaksi == model.JenisBiaya {
+			biaya += trx.Nominal
+		}
+	}
+	return biaya, nil
+}
+
+// tagihBiaya menagih sisa tagihan dari saldo rekening di atas saldo minimum produk. Biaya
+// tidak pernah membuat saldo turun di bawah saldo minimum: jika saldo kurang, kebijakan
+// menentukan apakah biaya dilewati, ditagih sebagian, atau ditagih sebagian dan kekurangannya
+// dicatat sebagai tunggakan. Rekening yang tidak boleh didebit (dormant, dibekukan, ditutup)
+// tidak ditagih sama sekali dan seluruh sisanya dicatat sebagai tunggakan. Tagihan baru (ID 0)
+// disimpan, tagihan lama diperbarui, dan yang tertagih dibukukan sebagai transaksi biaya.
+func tagihBiaya(repos repository.Repositories, rekening *model.Rekening, produk model.Produk, tagihan model.TagihanBiaya, kebijakan string) (model.TagihanBiaya, error) {
+	sisa := tagihan.Sisa()
+	tersedia := rekening.Saldo - produk.SaldoMinimum
+	if tersedia < 0 {
+		tersedia = 0
+	}
+	fields := logrus.Fields{
+		"no_rekening":   rekening.NoRekening,
+		"status":        rekening.Status,
+		"jenis_biaya":   tagihan.JenisBiaya,
+		"sisa":          sisa,
+		"saldo":         rekening.Saldo,
+		"saldo_minimum": produk.SaldoMinimum,
+		"kebijakan":     kebijakan,
+		"action":        "tagihBiaya",
+		"layer":         "allUsecase",
+	}
+
+	tagih, status := sisa, model.StatusTagihanLunas
+	switch {
+	case rekening.Status == model.StatusDormant || rekening.Status == model.StatusDibekukan || rekening.Status == model.StatusDitutup:
+		tagih, status = 0, model.StatusTagihanTunggakan
+		utils.Log.WithFields(fields).Warn("Rekening tidak bisa didebit, biaya dicatat sebagai tunggakan")
+	case tersedia < sisa:
+		switch kebijakan {
+		case model.KebijakanLewati:
+			tagih, status = 0, model.StatusTagihanDilewati
+		case model.KebijakanSebagian:
+			tagih, status = tersedia, model.StatusTagihanSebagian
+		default:
+			tagih, status = tersedia, model.StatusTagihanTunggakan
+		}
+		utils.Log.WithFields(fields).Warn("Saldo di atas saldo minimum tidak cukup untuk membayar biaya penuh")
+	}
+
+	tagihan.Tertagih += tagih
+	tagihan.Status = status
+	if tagihan.ID == 0 {
+		var err error
+		if tagihan, err = repos.BiayaRepository.CreateTagihan(tagihan); err != nil {
+			return model.TagihanBiaya{}, err
+		}
+	} else if tagih > 0 {
+		if err := repos.BiayaRepository.UpdateTagihan(tagihan); err != nil {
+			return model.TagihanBiaya{}, err
+		}
+	}
+	if tagih == 0 {
+		return tagihan, nil
+	}
+	if err := bukukanBiaya(repos, rekening, tagihan, tagih); err != nil {
+		return model.TagihanBiaya{}, err
+	}
+	return tagihan, nil
+}
+
+// tagihTunggakanPenutupan melunasi semua tunggakan dari saldo rekening yang akan ditutup.
+// Saldo minimum produk dan status rekening tidak berlaku lagi karena sisa saldo akan dicairkan
+// seluruhnya. Jika saldo tidak cukup, penutupan ditolak dengan ErrTunggakanBelumLunas.
+func tagihTunggakanPenutupan(repos repository.Repositories, rekening *model.Rekening) error {
+	tunggakan, err := repos.BiayaRepository.FindTunggakan(rekening.ID)
+	if err != nil {
+		return err
+	}
+	for _, tagihan := range tunggakan {
+		tagih := tagihan.Sisa()
+		if rekening.Saldo < tagih {
+			utils.Log.WithFields(logrus.Fields{
+				"no_rekening":      rekening.NoRekening,
+				"tagihan_biaya_id": tagihan.ID,
+				"sisa":             tagih,
+				"saldo":            rekening.Saldo,
+				"action":           "tagihTunggakanPenutupan",
+				"layer":            "allUsecase",
+			}).Warn("Saldo tidak cukup melunasi tunggakan biaya sebelum penutupan")
+			return ErrTunggakanBelumLunas
+		}
+
+		tagihan.Tertagih += tagih
+		tagihan.Status = model.StatusTagihanLunas
+		if err := repos.BiayaRepository.UpdateTagihan(tagihan); err != nil {
+			return err
+		}
+		if err := bukukanBiaya(repos, rekening, tagihan, tagih); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bukukanBiaya mendebit rekening sebesar tagih untuk tagihan ke pendapatan biaya
+func bukukanBiaya(repos repository.Repositories, rekening *model.Rekening, tagihan model.TagihanBiaya, tagih model.Money) error {
+	keterangan := fmt.Sprintf("biaya %s rekening %s", tagihan.JenisBiaya, rekening.NoRekening)
+	_, err := bukukanMutasi(repos, rekening, model.Transaksi{
+		JenisTransaksi:    model.JenisBiaya,
+		Nominal:           tagih,
+		TransaksiPemicuID: tagihan.TransaksiPemicuID,
+		TagihanBiayaID:    &tagihan.ID,
+	}, keterangan, []entriJurnal{
+		{Rekening: rekening, Debit: tagih},
+		{KodeAkun: model.AkunPendapatanBiaya, Kredit: tagih},
+	})
+	return err
+}
+
+// batalkanTagihanBiaya menandai tagihan yang ikut dikoreksi sebagai dibatalkan, yaitu tagihan
+// yang dipicu salah satu legs dan tagihan dari transaksi biaya di antara baris yang dikoreksi.
+// Tertagih dikurangi sebesar biaya yang dikembalikan dan sisanya tidak ditagih lagi.
+func batalkanTagihanBiaya(repos repository.Repositories, legs, baris []model.Transaksi) error {
+	var urutan []int
+	dikembalikan := make(map[int]model.Money)
+	tandai := func(tagihanID int, nominal model.Money) {
+		if _, ok := dikembalikan[tagihanID]; !ok {
+			urutan = append(urutan, tagihanID)
+		}
+		dikembalikan[tagihanID] += nominal
+	}
+	for _, b := range baris {
+		if b.JenisTransaksi == model.JenisBiaya && b.TagihanBiayaID != nil {
+			tandai(*b.TagihanBiayaID, b.Nominal)
+		}
+	}
+	for _, leg := range legs {
+		tagihan, err := repos.BiayaRepository.FindTagihanByPemicu(leg.ID)
+		if err != nil {
+			return err
+		}
+		if tagihan.ID != 0 {
+			tandai(tagihan.ID, 0)
+		}
+	}
+
+	for _, id := range urutan {
+		tagihan, err := repos.BiayaRepository.FindTagihanByID(id)
+		if err != nil {
+			return err
+		}
+		if tagihan.ID == 0 {
+			continue
+		}
+		tagihan.Tertagih -= dikembalikan[id]
+		tagihan.Status = model.StatusTagihanDibatalkan
+		if err := repos.BiayaRepository.UpdateTagihan(tagihan); err != nil {
+			return err
+		}
+		utils.Log.WithFields(logrus.Fields{
+			"tagihan_biaya_id": tagihan.ID,
+			"jenis_biaya":      tagihan.JenisBiaya,
+			"dikembalikan":     dikembalikan[id],
+			"action":           "batalkanTagihanBiaya",
+			"layer":            "allUsecase",
+		}).Info("Tagihan biaya dibatalkan karena transaksinya dikoreksi")
+	}
+	return nil
+}
+
+func NewBiayaUsecase(rekeningRepository repository.RekeningRepository, biayaRepository repository.BiayaRepository, unitOfWork repository.UnitOfWork, clock utils.Clock) BiayaUsecase {
+	return &biayaUsecase{
+		RekeningRepository: rekeningRepository,
+		BiayaRepository:    biayaRepository,
+		UnitOfWork:         unitOfWork,
+		Clock:              clock,
+	}
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+)
+
+const rekeningBiayaID = 2
+
+// siapkanBiaya menyusun repository palsu untuk satu rekening beserta produknya
+func siapkanBiaya(rekening model.Rekening, produk model.Produk, tagihan ...model.TagihanBiaya) repository.Repositories {
+	rekening.ID = rekeningBiayaID
+	biayaRepo := &fakeBiayaRepository{tagihan: map[int]model.TagihanBiaya{}}
+	for _, t := range tagihan {
+		biayaRepo.tagihan[t.ID] = t
+	}
+	return repository.Repositories{
+		RekeningRepository:  &fakeRekeningRepository{rekening: map[string]model.Rekening{rekening.NoRekening: rekening}},
+		TransaksiRepository: &fakeTransaksiRepository{},
+		AuditRepository:     fakeAuditRepository{},
+		ProdukRepository:    &fakeProdukRepository{produk: produk},
+		BiayaRepository:     biayaRepo,
+		BukuBesarRepository: &fakeBukuBesarRepository{
+			akun:         map[string]int{model.AkunPendapatanBiaya: 12, model.AkunSuspense: 13},
+			akunRekening: map[int]int{rekening.ID: akunRekeningUji},
+			saldo:        map[int]model.Money{akunRekeningUji: rekening.Saldo},
+		},
+	}
+}
+
+func TestTagihBiaya(t *testing.T) {
+	produk := model.Produk{SaldoMinimum: model.Rupiah(50_000)}
+	biaya := model.Rupiah(6_500)
+	baru := model.TagihanBiaya{RekeningID: rekeningBiayaID, JenisBiaya: model.BiayaTransfer, Nominal: biaya}
+	lama := model.TagihanBiaya{ID: 1, RekeningID: rekeningBiayaID, JenisBiaya: model.BiayaAdminBulanan, Nominal: biaya, Tertagih: model.Rupiah(2_000), Status: model.StatusTagihanTunggakan}
+
+	tests := []struct {
+		nama          string
+		status        string
+		saldo         model.Money
+		tagihan       model.TagihanBiaya
+		kebijakan     string
+		harapTertagih model.Money
+		harapStatus   string
+		harapSaldo    model.Money
+	}{
+		{"saldo cukup ditagih penuh", model.StatusAktif, model.Rupiah(100_000), baru, model.KebijakanTunggakan, biaya, model.StatusTagihanLunas, model.Rupiah(93_500)},
+		{"kekurangan dicatat sebagai tunggakan", model.StatusAktif, model.Rupiah(52_000), baru, model.KebijakanTunggakan, model.Rupiah(2_000), model.StatusTagihanTunggakan, model.Rupiah(50_000)},
+		{"kekurangan dihapus", model.StatusAktif, model.Rupiah(52_000), baru, model.KebijakanSebagian, model.Rupiah(2_000), model.StatusTagihanSebagian, model.Rupiah(50_000)},
+		{"kekurangan membuat biaya dilewati", model.StatusAktif, model.Rupiah(52_000), baru, model.KebijakanLewati, 0, model.StatusTagihanDilewati, model.Rupiah(52_000)},
+		{"saldo di bawah minimum tidak dipotong", model.StatusAktif, model.Rupiah(40_000), baru, model.KebijakanTunggakan, 0, model.StatusTagihanTunggakan, model.Rupiah(40_000)},
+		{"rekening dibekukan tidak didebit", model.StatusDibekukan, model.Rupiah(100_000), baru, model.KebijakanLewati, 0, model.StatusTagihanTunggakan, model.Rupiah(100_000)},
+		{"rekening dormant tidak didebit", model.StatusDormant, model.Rupiah(100_000), baru, model.KebijakanSebagian, 0, model.StatusTagihanTunggakan, model.Rupiah(100_000)},
+		{"tunggakan lama dilunasi", model.StatusAktif, model.Rupiah(100_000), lama, model.KebijakanTunggakan, biaya, model.StatusTagihanLunas, model.Rupiah(95_500)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			rekening := model.Rekening{NoRekening: "1000000002", Saldo: tt.saldo, Status: tt.status}
+			repos := siapkanBiaya(rekening, produk, lama)
+			rekening.ID = rekeningBiayaID
+
+			tagihan, err := tagihBiaya(repos, &rekening, produk, tt.tagihan, tt.kebijakan)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tagihan.Tertagih != tt.harapTertagih || tagihan.Status != tt.harapStatus {
+				t.Fatalf("tertagih %d status %s, seharusnya %d %s", tagihan.Tertagih, tagihan.Status, tt.harapTertagih, tt.harapStatus)
+			}
+			if tersimpan := repos.BiayaRepository.(*fakeBiayaRepository).tagihan[tagihan.ID]; tersimpan.Tertagih != tagihan.Tertagih || tersimpan.Status != tagihan.Status {
+				t.Fatalf("tagihan tersimpan tertagih %d status %s, seharusnya %d %s", tersimpan.Tertagih, tersimpan.Status, tagihan.Tertagih, tagihan.Status)
+			}
+			tersimpan, _ := repos.RekeningRepository.FindByNoREKForUpdate(rekening.NoRekening)
+			if rekening.Saldo != tt.harapSaldo || tersimpan.Saldo != tt.harapSaldo {
+				t.Fatalf("saldo %d (tersimpan %d), seharusnya %d", rekening.Saldo, tersimpan.Saldo, tt.harapSaldo)
+			}
+		})
+	}
+}
+
+func TestBiayaTarikJatahGratisBulanan(t *testing.T) {
+	tanggal := func(tahun int, bulan time.Month, hari, jam, menit, detik int) time.Time {
+		return time.Date(tahun, bulan, hari, jam, menit, detik, 0, time.Local)
+	}
+	produk := model.Produk{BiayaTarik: model.Rupiah(5_000), GratisTarikPerBulan: 2, KebijakanBiaya: model.KebijakanTunggakan}
+
+	tests := []struct {
+		nama        string
+		now         time.Time
+		jumlahTarik int
+		harapDari   time.Time
+		harapSampai time.Time
+		harapBiaya  model.Money
+	}{
+		{"masih dalam jatah gratis", tanggal(2026, time.January, 31, 23, 59, 59), 2, tanggal(2026, time.January, 1, 0, 0, 0), tanggal(2026, time.February, 1, 0, 0, 0), 0},
+		{"melebihi jatah gratis", tanggal(2026, time.January, 31, 23, 59, 59), 3, tanggal(2026, time.January, 1, 0, 0, 0), tanggal(2026, time.February, 1, 0, 0, 0), model.Rupiah(5_000)},
+		{"awal bulan baru memakai jatah bulan baru", tanggal(2026, time.February, 1, 0, 0, 0), 1, tanggal(2026, time.February, 1, 0, 0, 0), tanggal(2026, time.March, 1, 0, 0, 0), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			rekening := model.Rekening{NoRekening: "1000000002", Saldo: model.Rupiah(100_000), Status: model.StatusAktif}
+			repos := siapkanBiaya(rekening, produk)
+			rekening.ID = rekeningBiayaID
+			transaksiRepo := repos.TransaksiRepository.(*fakeTransaksiRepository)
+			transaksiRepo.rekap = model.RekapHarian{Jumlah: tt.jumlahTarik}
+
+			biaya, err := biayaTarik(repos, &rekening, model.Transaksi{ID: 99, JenisTransaksi: model.JenisTarik}, tt.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !transaksiRepo.rekapDari.Equal(tt.harapDari) || !transaksiRepo.rekapSampai.Equal(tt.harapSampai) {
+				t.Fatalf("jatah dihitung pada [%v, %v), seharusnya [%v, %v)", transaksiRepo.rekapDari, transaksiRepo.rekapSampai, tt.harapDari, tt.harapSampai)
+			}
+			var dikenakan model.Money
+			for _, trx := range transaksiRepo.transaksi {
+				dikenakan += trx.Nominal
+			}
+			if dikenakan != tt.harapBiaya || biaya != tt.harapBiaya {
+				t.Fatalf("biaya tarik %d (dilaporkan %d), seharusnya %d", dikenakan, biaya, tt.harapBiaya)
+			}
+		})
+	}
+}
+
+func TestTagihTunggakanPenutupan(t *testing.T) {
+	produk := model.Produk{SaldoMinimum: model.Rupiah(50_000)}
+	tunggakan := []model.TagihanBiaya{
+		{ID: 1, RekeningID: rekeningBiayaID, JenisBiaya: model.BiayaAdminBulanan, Nominal: model.Rupiah(6_500), Tertagih: model.Rupiah(1_500), Status: model.StatusTagihanTunggakan},
+		{ID: 2, RekeningID: rekeningBiayaID, JenisBiaya: model.BiayaTarik, Nominal: model.Rupiah(5_000), Status: model.StatusTagihanTunggakan},
+	}
+
+	tests := []struct {
+		nama       string
+		status     string
+		saldo      model.Money
+		harapErr   error
+		harapSaldo model.Money
+	}{
+		{"tunggakan dilunasi di bawah saldo minimum", model.StatusAktif, model.Rupiah(12_000), nil, model.Rupiah(2_000)},
+		{"rekening dormant tetap didebit", model.StatusDormant, model.Rupiah(10_000), nil, 0},
+		{"saldo tidak cukup menolak penutupan", model.StatusDibekukan, model.Rupiah(9_999), ErrTunggakanBelumLunas, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			rekening := model.Rekening{NoRekening: "1000000002", Saldo: tt.saldo, Status: tt.status}
+			repos := siapkanBiaya(rekening, produk, tunggakan...)
+			rekening.ID = rekeningBiayaID
+
+			err := tagihTunggakanPenutupan(repos, &rekening)
+			if !errors.Is(err, tt.harapErr) {
+				t.Fatalf("error %v, seharusnya %v", err, tt.harapErr)
+			}
+			if err != nil {
+				return
+			}
+			if rekening.Saldo != tt.harapSaldo {
+				t.Fatalf("saldo %d, seharusnya %d", rekening.Saldo, tt.harapSaldo)
+			}
+			for _, tagihan := range repos.BiayaRepository.(*fakeBiayaRepository).tagihan {
+				if tagihan.Status != model.StatusTagihanLunas || tagihan.Sisa() != 0 {
+					t.Fatalf("tagihan %d berstatus %s sisa %d, seharusnya lunas", tagihan.ID, tagihan.Status, tagihan.Sisa())
+				}
+			}
+			bukuBesar := repos.BukuBesarRepository.(*fakeBukuBesarRepository)
+			if pendapatan := bukuBesar.saldo[bukuBesar.akun[model.AkunPendapatanBiaya]]; pendapatan != model.Rupiah(10_000) {
+				t.Fatalf("pendapatan biaya %d, seharusnya %d", pendapatan, model.Rupiah(10_000))
+			}
+		})
+	}
+}
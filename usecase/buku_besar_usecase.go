@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errJurnalTidakSeimbang = errors.New("jurnal tidak seimbang")
+	errSaldoTidakCocok     = errors.New("saldo rekening tidak cocok dengan buku besar")
+)
+
+// entriJurnal adalah satu sisi jurnal. Untuk rekening nasabah, Rekening.Saldo harus sudah
+// berisi saldo setelah jurnal ini; untuk akun internal, isi KodeAkun.
+type entriJurnal struct {
+	Rekening *model.Rekening
+	KodeAkun string
+	Debit    model.Money
+	Kredit   model.Money
+}
+
+// postJurnal membukukan jurnal yang seimbang lalu menyimpan saldo baru setiap rekening yang
+// terlibat. Saldo di tabel rekening hanya cache: sebelum disimpan, nilainya dicocokkan dengan
+// saldo yang dihitung dari seluruh posting akun rekening tersebut.
+func postJurnal(repos repository.Repositories, noReferensi, keterangan string, entri []entriJurnal) (model.Jurnal, error) {
+	var totalDebit, totalKredit model.Money
+	postings := make([]model.Posting, 0, len(entri))
+	for _, e := range entri {
+		if (e.Debit > 0) == (e.Kredit > 0) || e.Debit < 0 || e.Kredit < 0 {
+			return model.Jurnal{}, fmt.Errorf("%w: posting harus berisi tepat satu sisi positif", errJurnalTidakSeimbang)
+		}
+		totalDebit += e.Debit
+		totalKredit += e.Kredit
+
+		akun, err := akunEntri(repos, e)
+		if err != nil {
+			return model.Jurnal{}, err
+		}
+		postings = append(postings, model.Posting{AkunBukuID: akun.ID, Debit: e.Debit, Kredit: e.Kredit})
+	}
+	if totalDebit != totalKredit {
+		return model.Jurnal{}, fmt.Errorf("%w: debit %s, kredit %s", errJurnalTidakSeimbang, totalDebit, totalKredit)
+	}
+
+	jurnal, err := repos.BukuBesarRepository.CreateJurnal(model.Jurnal{
+		NoReferensi: noReferensi,
+		Keterangan:  keterangan,
+		Posting:     postings,
+	})
+	if err != nil {
+		return model.Jurnal{}, err
+	}
+
+	for i, e := range entri {
+		if e.Rekening == nil {
+			continue
+		}
+		saldoBukuBesar, err := repos.BukuBesarRepository.SaldoKewajiban(postings[i].AkunBukuID)
+		if err != nil {
+			return model.Jurnal{}, err
+		}
+		if saldoBukuBesar != e.Rekening.Saldo {
+			utils.Log.WithFields(logrus.Fields{
+				"no_rekening":      e.Rekening.NoRekening,
+				"saldo_rekening":   e.Rekening.Saldo,
+				"saldo_buku_besar": saldoBukuBesar,
+				"jurnal_id":        jurnal.ID,
+				"action":           "postJurnal",
+				"layer":            "allUsecase",
+			}).Error("Saldo rekening tidak cocok dengan buku besar, transaksi dibatalkan")
+			return model.Jurnal{}, errSaldoTidakCocok
+		}
+		if _, err := repos.RekeningRepository.UpdateSaldo(*e.Rekening); err != nil {
+			return model.Jurnal{}, err
+		}
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"jurnal_id":    jurnal.ID,
+		"no_referensi": noReferensi,
+		"total":        totalDebit,
+		"action":       "postJurnal",
+		"layer":        "allUsecase",
+	}).Info("Jurnal berhasil dibukukan")
+	return jurnal, nil
+}
+
+// bukukanMutasi mengubah saldo rekening sebesar transaksi.Nominal sesuai jenisnya, membukukan
+// jurnalnya, lalu mencatat transaksi dan audit saldo. Dipakai untuk mutasi yang dibuat bank
+// sendiri seperti bunga, pajak dan biaya. Entri jurnal harus menunjuk ke rekening yang sama.
+func bukukanMutasi(repos repository.Repositories, rekening *model.Rekening, transaksi model.Transaksi, keterangan string, entri []entriJurnal) (model.Transaksi, error) {
+	saldoAwal := rekening.Saldo
+	if model.IsDebit(transaksi.JenisTransaksi) {
+		rekening.Saldo -= transaksi.Nominal
+	} else {
+		rekening.Saldo += transaksi.Nominal
+	}
+
+	jurnal, err := postJurnal(repos, "", keterangan, entri)
+	if err != nil {
+		return model.Transaksi{}, err
+	}
+
+	transaksi.RekeningID = rekening.ID
+	transaksi.SaldoAwal = saldoAwal
+	transaksi.SaldoAkhir = rekening.Saldo
+	transaksi.JurnalID = &jurnal.ID
+	transaksi, err = repos.TransaksiRepository.Create(transaksi)
+	if err != nil {
+		return model.Transaksi{}, err
+	}
+	if err := recordSaldo(repos, *rekening, transaksi); err != nil {
+		return model.Transaksi{}, err
+	}
+	return transaksi, nil
+}
+
+func akunEntri(repos repository.Repositories, e entriJurnal) (model.AkunBuku, error) {
+	var (
+		akun model.AkunBuku
+		err  error
+	)
+	if e.Rekening != nil {
+		akun, err = repos.BukuBesarRepository.FindAkunByRekeningID(e.Rekening.ID)
+	} else {
+		akun, err = repos.BukuBesarRepository.FindAkunByKode(e.KodeAkun)
+	}
+	if err != nil {
+		return model.AkunBuku{}, err
+	}
+	if akun.ID == 0 && e.Rekening != nil {
+		return model.AkunBuku{}, fmt.Errorf("akun buku besar rekening %s tidak ditemukan", e.Rekening.NoRekening)
+	}
+	if akun.ID == 0 {
+		return model.AkunBuku{}, fmt.Errorf("akun buku besar %s tidak ditemukan", e.KodeAkun)
+	}
+	return akun, nil
+}
+
+// createAkunRekening membuat akun buku besar untuk rekening yang baru dibuka
+func createAkunRekening(repos repository.Repositories, rekening model.Rekening) error {
+	rekeningID := rekening.ID
+	_, err := repos.BukuBesarRepository.CreateAkun(model.AkunBuku{
+		Kode:       model.KodeAkunRekening(rekening.NoRekening),
+		Nama:       "Rekening " + rekening.NoRekening,
+		Jenis:      model.JenisAkunKewajiban,
+		RekeningID: &rekeningID,
+	})
+	return err
+}
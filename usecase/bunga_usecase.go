@@ -0,0 +1,285 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	bungaBatch    = 500
+	formatPeriode = "2006-01"
+)
+
+// BungaUsecase mengakru bunga harian dari snapshot saldo penutupan dan mengkreditkannya
+// setiap bulan. Semua perhitungan hanya bergantung pada saldo_harian dan suku_bunga yang
+// berlaku pada tanggal tersebut, sedangkan Clock hanya dipakai untuk menentukan apakah
+// sebuah periode sudah berakhir.
+type BungaUsecase interface {
+	Proses() (model.HasilBunga, error)
+	Akru(tanggal time.Time) (int, error)
+	Kreditkan(periode time.Time) (int, error)
+}
+
+type bungaUsecase struct {
+	TutupHariRepository repository.TutupHariRepository
+	BungaRepository     repository.BungaRepository
+	UnitOfWork          repository.UnitOfWork
+	Clock               utils.Clock
+	// PajakPersen dipotong dari bunga rekening yang saldo akhir periodenya melebihi BatasSaldoPajak
+	PajakPersen     int
+	BatasSaldoPajak model.Money
+}
+
+// Proses mengakru semua tanggal buku yang sudah ditutup tetapi belum diakru, lalu
+// mengkreditkan setiap periode yang sudah berakhir dan sudah diakru sampai hari terakhirnya
+func (u *bungaUsecase) Proses() (model.HasilBunga, error) {
+	hasil := model.HasilBunga{TanggalDiakru: []string{}, PeriodeDikreditkan: []string{}}
+
+	tanggal, err := u.BungaRepository.FindTanggalBelumDiakru()
+	if err != nil {
+		return hasil, err
+	}
+	for _, t := range tanggal {
+		if _, err := u.Akru(t); err != nil {
+			return hasil, err
+		}
+		hasil.TanggalDiakru = append(hasil.TanggalDiakru, awalHari(t).Format(formatTanggal))
+	}
+
+	periode, err := u.BungaRepository.FindPeriodeBelumDikreditkan()
+	if err != nil {
+		return hasil, err
+	}
+	for _, p := range periode {
+		jumlah, err := u.Kreditkan(p)
+		if errors.Is(err, ErrPeriodeBungaBerjalan) || errors.Is(err, ErrPeriodeBungaBelumAkru) {
+			continue
+		}
+		if err != nil {
+			return hasil, err
+		}
+		hasil.PeriodeDikreditkan = append(hasil.PeriodeDikreditkan, p.Format(formatPeriode))
+		hasil.RekeningDikreditkan += jumlah
+	}
+	return hasil, nil
+}
+
+// Akru menghitung bunga harian setiap rekening dari saldo penutupan tanggal tersebut.
+// Aman diulang: akru yang sudah tersimpan tidak dihitung ulang.
+func (u *bungaUsecase) Akru(tanggal time.Time) (int, error) {
+	tanggal = awalHari(tanggal)
+	fields := logrus.Fields{
+		"tanggal": tanggal.Format(formatTanggal),
+		"action":  "AkruBunga",
+		"layer":   "bungaUsecase",
+	}
+
+	tutupHari, err := u.TutupHariRepository.FindByTanggal(tanggal)
+	if err != nil {
+		return 0, err
+	}
+	if tutupHari.Status != model.StatusTutupHariSelesai {
+		utils.Log.WithFields(fields).Warn("Tanggal buku belum selesai ditutup")
+		return 0, ErrAkruBelumTutupHari
+	}
+
+	sukuBunga := make(map[string][]model.SukuBunga)
+	lastID := 0
+	for {
+		saldo, err := u.TutupHariRepository.FindSaldoHarian(tanggal, lastID, bungaBatch)
+		if err != nil {
+			return 0, err
+		}
+		for _, s := range saldo {
+			lastID = s.RekeningID
+
+			tier, ok := sukuBunga[s.KodeProduk]
+			if !ok {
+				if tier, err = u.BungaRepository.FindSukuBunga(s.KodeProduk, tanggal); err != nil {
+					return 0, err
+				}
+				sukuBunga[s.KodeProduk] = tier
+			}
+			berlaku, ok := model.PilihSukuBunga(tier, s.SaldoAkhir)
+			if !ok {
+				continue
+			}
+			bunga := model.HitungBungaHarian(s.SaldoAkhir, berlaku.BungaTahunanBps)
+			if bunga == 0 {
+				continue
+			}
+
+			err = u.BungaRepository.CreateBungaHarian(model.BungaHarian{
+				Tanggal:         tanggal,
+				RekeningID:      s.RekeningID,
+				Saldo:           s.SaldoAkhir,
+				BungaTahunanBps: berlaku.BungaTahunanBps,
+				BungaMikrosen:   bunga,
+			})
+			if err != nil {
+				return 0, err
+			}
+		}
+		if len(saldo) < bungaBatch {
+			break
+		}
+	}
+
+	jumlah, err := u.BungaRepository.CountBungaHarian(tanggal)
+	if err != nil {
+		return 0, err
+	}
+	if err := u.BungaRepository.CreateAkru(model.AkruBunga{Tanggal: tanggal, JumlahRekening: jumlah}); err != nil {
+		return 0, err
+	}
+	utils.Log.WithFields(fields).WithField("jumlah_rekening", jumlah).Info("Bunga harian selesai diakru")
+	return jumlah, nil
+}
+
+// Kreditkan membukukan akru bunga satu periode bulan ke setiap rekening sebagai transaksi
+// bunga, diikuti transaksi pajak jika saldo akhir periodenya kena pajak. Setiap rekening
+// dikreditkan di transaksi databasenya sendiri dan akrunya ditandai di transaksi yang sama,
+// sehingga proses yang terhenti bisa dijalankan ulang tanpa kredit ganda.
+func (u *bungaUsecase) Kreditkan(periode time.Time) (int, error) {
+	dari := time.Date(periode.Year(), periode.Month(), 1, 0, 0, 0, 0, time.Local)
+	sampai := dari.AddDate(0, 1, 0)
+	fields := logrus.Fields{
+		"periode": dari.Format(formatPeriode),
+		"action":  "KreditkanBunga",
+		"layer":   "bungaUsecase",
+	}
+
+	if awalHari(u.Clock.Now()).Before(sampai) {
+		utils.Log.WithFields(fields).Info("Periode bunga belum berakhir")
+		return 0, ErrPeriodeBungaBerjalan
+	}
+	lengkap, err := u.BungaRepository.IsDiakru(sampai.AddDate(0, 0, -1))
+	if err != nil {
+		return 0, err
+	}
+	if !lengkap {
+		utils.Log.WithFields(fields).Warn("Bunga periode belum diakru sampai hari terakhir")
+		return 0, ErrPeriodeBungaBelumAkru
+	}
+
+	jumlah := 0
+	lastID := 0
+	for {
+		rekenings, err := u.BungaRepository.FindRekeningBelumDikreditkan(dari, sampai, lastID, bungaBatch)
+		if err != nil {
+			return jumlah, err
+		}
+		for _, rekening := range rekenings {
+			dikreditkan, err := u.kreditkanRekening(rekening.NoRekening, dari, sampai)
+			if err != nil {
+				utils.Log.WithError(err).WithFields(fields).WithField("no_rekening", rekening.NoRekening).Error("Kredit bunga terhenti, jalankan ulang untuk melanjutkan")
+				return jumlah, err
+			}
+			if dikreditkan {
+				jumlah++
+			}
+			lastID = rekening.ID
+		}
+		if len(rekenings) < bungaBatch {
+			break
+		}
+	}
+
+	utils.Log.WithFields(fields).WithField("jumlah_rekening", jumlah).Info("Bunga periode selesai dikreditkan")
+	return jumlah, nil
+}
+
+func (u *bungaUsecase) kreditkanRekening(noREK string, dari, sampai time.Time) (bool, error) {
+	var dikreditkan bool
+	err := u.UnitOfWork.Do(func(repos repository.Repositories) error {
+		rekening, err := repos.RekeningRepository.FindByNoREKForUpdate(noREK)
+		if err != nil {
+			return err
+		}
+
+		rekap, err := repos.BungaRepository.RekapBelumDikreditkan(rekening.ID, dari, sampai)
+		if err != nil {
+			return err
+		}
+		if rekap.JumlahHari == 0 {
+			return nil
+		}
+		if rekening.Status == model.StatusDitutup {
+			utils.Log.WithFields(logrus.Fields{
+				"no_rekening": rekening.NoRekening,
+				"periode":     dari.Format(formatPeriode),
+				"action":      "kreditkanRekening",
+				"layer":       "bungaUsecase",
+			}).Warn("Rekening sudah ditutup, bunga tidak dikreditkan")
+			return nil
+		}
+
+		// sisa pecahan sen dari akru sebulan dibulatkan ke bawah
+		bunga := model.MikrosenKeMoney(rekap.TotalMikrosen)
+		var transaksiID *int
+		if bunga > 0 {
+			keterangan := fmt.Sprintf("bunga periode %s rekening %s", dari.Format(formatPeriode), rekening.NoRekening)
+			transaksiBunga, err := bukukanMutasi(repos, &rekening, model.Transaksi{JenisTransaksi: model.JenisBunga, Nominal: bunga}, keterangan, []entriJurnal{
+				{KodeAkun: model.AkunBebanBunga, Debit: bunga},
+				{Rekening: &rekening, Kredit: bunga},
+			})
+			if err != nil {
+				return err
+			}
+			transaksiID = &transaksiBunga.ID
+
+			pajak, err := u.hitungPajak(repos, rekening.ID, bunga, dari, sampai)
+			if err != nil {
+				return err
+			}
+			if pajak > 0 {
+				keterangan := fmt.Sprintf("pajak bunga periode %s rekening %s", dari.Format(formatPeriode), rekening.NoRekening)
+				// pajak menunjuk ke bunganya agar ikut dikoreksi bersama bunga tersebut
+				_, err := bukukanMutasi(repos, &rekening, model.Transaksi{JenisTransaksi: model.JenisPajak, Nominal: pajak, TransaksiPemicuID: transaksiID}, keterangan, []entriJurnal{
+					{Rekening: &rekening, Debit: pajak},
+					{KodeAkun: model.AkunUtangPajak, Kredit: pajak},
+				})
+				if err != nil {
+					return err
+				}
+			}
+			dikreditkan = true
+		}
+
+		return repos.BungaRepository.TandaiDikreditkan(rekening.ID, dari, sampai, transaksiID)
+	})
+	return dikreditkan, err
+}
+
+// hitungPajak memotong PajakPersen dari bunga jika saldo penutupan terakhir rekening pada
+// periode tersebut melebihi BatasSaldoPajak
+func (u *bungaUsecase) hitungPajak(repos repository.Repositories, rekeningID int, bunga model.Money, dari, sampai time.Time) (model.Money, error) {
+	if u.PajakPersen <= 0 {
+		return 0, nil
+	}
+	saldo, err := repos.TutupHariRepository.FindSaldoHarianTerakhir(rekeningID, dari, sampai)
+	if err != nil {
+		return 0, err
+	}
+	if saldo.SaldoAkhir <= u.BatasSaldoPajak {
+		return 0, nil
+	}
+	return bunga * model.Money(u.PajakPersen) / 100, nil
+}
+
+func NewBungaUsecase(tutupHariRepository repository.TutupHariRepository, bungaRepository repository.BungaRepository, unitOfWork repository.UnitOfWork, clock utils.Clock, pajakPersen int, batasSaldoPajak model.Money) BungaUsecase {
+	return &bungaUsecase{
+		TutupHariRepository: tutupHariRepository,
+		BungaRepository:     bungaRepository,
+		UnitOfWork:          unitOfWork,
+		Clock:               clock,
+		PajakPersen:         pajakPersen,
+		BatasSaldoPajak:     batasSaldoPajak,
+	}
+}
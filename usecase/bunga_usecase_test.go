@@ -0,0 +1,211 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+)
+
+type fakeBungaRepository struct {
+	repository.BungaRepository
+	diakruSampai  time.Time
+	ditanyaDiakru time.Time
+	rekening      []model.Rekening
+	rekap         map[int]model.RekapBunga
+	dikreditkan   map[int]*int
+}
+
+func (f *fakeBungaRepository) IsDiakru(tanggal time.Time) (bool, error) {
+	f.ditanyaDiakru = tanggal
+	return !tanggal.After(f.diakruSampai), nil
+}
+
+func (f *fakeBungaRepository) FindRekeningBelumDikreditkan(dari, sampai time.Time, afterID int, limit int) ([]model.Rekening, error) {
+	var hasil []model.Rekening
+	for _, r := range f.rekening {
+		if _, ok := f.dikreditkan[r.ID]; !ok && r.ID > afterID && len(hasil) < limit {
+			hasil = append(hasil, r)
+		}
+	}
+	return hasil, nil
+}
+
+func (f *fakeBungaRepository) RekapBelumDikreditkan(rekeningID int, dari, sampai time.Time) (model.RekapBunga, error) {
+	if _, ok := f.dikreditkan[rekeningID]; ok {
+		return model.RekapBunga{}, nil
+	}
+	return f.rekap[rekeningID], nil
+}
+
+func (f *fakeBungaRepository) TandaiDikreditkan(rekeningID int, dari, sampai time.Time, transaksiID *int) error {
+	f.dikreditkan[rekeningID] = transaksiID
+	return nil
+}
+
+func (f *fakeBungaRepository) BatalkanKredit(transaksiID int) error {
+	for rekeningID, id := range f.dikreditkan {
+		if id != nil && *id == transaksiID {
+			delete(f.dikreditkan, rekeningID)
+		}
+	}
+	return nil
+}
+
+const (
+	rekeningBungaID = 1
+	akunRekeningUji = 100
+)
+
+// siapkanKreditBunga menyusun bungaUsecase dengan satu rekening yang punya akru bunga
+// totalMikrosen dan saldo penutupan terakhir periode saldoAkhirPeriode
+func siapkanKreditBunga(sekarang, diakruSampai time.Time, saldo, saldoAkhirPeriode model.Money, totalMikrosen int64) (*bungaUsecase, *fakeBungaRepository, repository.Repositories) {
+	rekening := model.Rekening{ID: rekeningBungaID, NoRekening: "1000000001", Saldo: saldo, Status: model.StatusAktif}
+	bungaRepo := &fakeBungaRepository{
+		diakruSampai: diakruSampai,
+		rekening:     []model.Rekening{rekening},
+		rekap:        map[int]model.RekapBunga{rekening.ID: {TotalMikrosen: totalMikrosen, JumlahHari: 31}},
+		dikreditkan:  map[int]*int{},
+	}
+	repos := repository.Repositories{
+		RekeningRepository:  &fakeRekeningRepository{rekening: map[string]model.Rekening{rekening.NoRekening: rekening}},
+		TransaksiRepository: &fakeTransaksiRepository{},
+		AuditRepository:     fakeAuditRepository{},
+		BukuBesarRepository: &fakeBukuBesarRepository{
+			akun:         map[string]int{model.AkunBebanBunga: 10, model.AkunUtangPajak: 11},
+			akunRekening: map[int]int{rekening.ID: akunRekeningUji},
+			saldo:        map[int]model.Money{akunRekeningUji: saldo},
+		},
+		TutupHariRepository: &fakeTutupHariRepository{saldoAkhir: map[int]model.Money{rekening.ID: saldoAkhirPeriode}},
+		BungaRepository:     bungaRepo,
+	}
+	u := NewBungaUsecase(repos.TutupHariRepository, bungaRepo, &fakeUnitOfWork{repos: repos}, jamTetap(sekarang), 20, model.Rupiah(7_500_000)).(*bungaUsecase)
+	return u, bungaRepo, repos
+}
+
+func TestKreditkanBunga(t *testing.T) {
+	tanggal := func(tahun int, bulan time.Month, hari, jam, menit, detik int) time.Time {
+		return time.Date(tahun, bulan, hari, jam, menit, detik, 0, time.Local)
+	}
+	saldo := model.Rupiah(1_000_000)
+	// 31 hari x 6.849.315.068 mikrosen = 212.328,767108 sen, sisanya dibuang
+	akruJanuari := int64(31 * 6_849_315_068)
+
+	tests := []struct {
+		nama              string
+		periode           time.Time
+		sekarang          time.Time
+		diakruSampai      time.Time
+		saldoAkhirPeriode model.Money
+		mikrosen          int64
+		harapErr          error
+		harapTanyaDiakru  time.Time
+		harapJumlah       int
+		harapBunga        model.Money
+		harapPajak        model.Money
+	}{
+		{
+			nama:         "detik terakhir periode masih berjalan",
+			periode:      tanggal(2026, time.January, 1, 0, 0, 0),
+			sekarang:     tanggal(2026, time.January, 31, 23, 59, 59),
+			diakruSampai: tanggal(2026, time.January, 31, 0, 0, 0),
+			mikrosen:     akruJanuari,
+			harapErr:     ErrPeriodeBungaBerjalan,
+		},
+		{
+			nama:             "februari kabisat belum diakru sampai tanggal 29",
+			periode:          tanggal(2024, time.February, 1, 0, 0, 0),
+			sekarang:         tanggal(2024, time.March, 1, 0, 0, 0),
+			diakruSampai:     tanggal(2024, time.February, 28, 0, 0, 0),
+			mikrosen:         akruJanuari,
+			harapErr:         ErrPeriodeBungaBelumAkru,
+			harapTanyaDiakru: tanggal(2024, time.February, 29, 0, 0, 0),
+		},
+		{
+			nama:              "awal bulan berikutnya dikreditkan tanpa pajak",
+			periode:           tanggal(2026, time.January, 15, 10, 30, 0),
+			sekarang:          tanggal(2026, time.February, 1, 0, 0, 0),
+			diakruSampai:      tanggal(2026, time.January, 31, 0, 0, 0),
+			saldoAkhirPeriode: saldo,
+			mikrosen:          akruJanuari,
+			harapTanyaDiakru:  tanggal(2026, time.January, 31, 0, 0, 0),
+			harapJumlah:       1,
+			harapBunga:        212_328,
+		},
+		{
+			nama:              "saldo tepat di batas tidak kena pajak",
+			periode:           tanggal(2026, time.January, 1, 0, 0, 0),
+			sekarang:          tanggal(2026, time.February, 3, 9, 0, 0),
+			diakruSampai:      tanggal(2026, time.February, 2, 0, 0, 0),
+			saldoAkhirPeriode: model.Rupiah(7_500_000),
+			mikrosen:          akruJanuari,
+			harapTanyaDiakru:  tanggal(2026, time.January, 31, 0, 0, 0),
+			harapJumlah:       1,
+			harapBunga:        212_328,
+		},
+		{
+			nama:              "saldo di atas batas dipotong pajak dibulatkan ke bawah",
+			periode:           tanggal(2026, time.January, 1, 0, 0, 0),
+			sekarang:          tanggal(2026, time.February, 1, 0, 0, 0),
+			diakruSampai:      tanggal(2026, time.January, 31, 0, 0, 0),
+			saldoAkhirPeriode: model.Rupiah(7_500_000) + 1,
+			mikrosen:          akruJanuari,
+			harapTanyaDiakru:  tanggal(2026, time.January, 31, 0, 0, 0),
+			harapJumlah:       1,
+			harapBunga:        212_328,
+			// 20% dari 212.328 sen = 42.465,6 sen
+			harapPajak: 42_465,
+		},
+		{
+			nama:              "akru di bawah satu sen tidak dikreditkan",
+			periode:           tanggal(2026, time.January, 1, 0, 0, 0),
+			sekarang:          tanggal(2026, time.February, 1, 0, 0, 0),
+			diakruSampai:      tanggal(2026, time.January, 31, 0, 0, 0),
+			saldoAkhirPeriode: saldo,
+			mikrosen:          model.MikrosenPerSen - 1,
+			harapTanyaDiakru:  tanggal(2026, time.January, 31, 0, 0, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			u, bungaRepo, repos := siapkanKreditBunga(tt.sekarang, tt.diakruSampai, saldo, tt.saldoAkhirPeriode, tt.mikrosen)
+
+			jumlah, err := u.Kreditkan(tt.periode)
+			if !errors.Is(err, tt.harapErr) {
+				t.Fatalf("error %v, seharusnya %v", err, tt.harapErr)
+			}
+			if jumlah != tt.harapJumlah {
+				t.Fatalf("jumlah rekening dikreditkan %d, seharusnya %d", jumlah, tt.harapJumlah)
+			}
+			if !bungaRepo.ditanyaDiakru.Equal(tt.harapTanyaDiakru) {
+				t.Fatalf("kelengkapan akru dicek untuk %v, seharusnya %v", bungaRepo.ditanyaDiakru, tt.harapTanyaDiakru)
+			}
+
+			var bunga, pajak model.Money
+			for _, trx := range repos.TransaksiRepository.(*fakeTransaksiRepository).transaksi {
+				switch trx.JenisTransaksi {
+				case model.JenisBunga:
+					bunga += trx.Nominal
+				case model.JenisPajak:
+					pajak += trx.Nominal
+				default:
+					t.Fatalf("transaksi %s tidak terduga", trx.JenisTransaksi)
+				}
+			}
+			if bunga != tt.harapBunga || pajak != tt.harapPajak {
+				t.Fatalf("bunga %d pajak %d, seharusnya bunga %d pajak %d", bunga, pajak, tt.harapBunga, tt.harapPajak)
+			}
+
+			rekening, _ := repos.RekeningRepository.FindByNoREKForUpdate("1000000001")
+			if harap := saldo + tt.harapBunga - tt.harapPajak; rekening.Saldo != harap {
+				t.Fatalf("saldo akhir %d, seharusnya %d", rekening.Saldo, harap)
+			}
+			_, ditandai := bungaRepo.dikreditkan[rekeningBungaID]
+			if ditandai != (tt.harapErr == nil) {
+				t.Fatalf("akru ditandai dikreditkan = %v, seharusnya %v", ditandai, tt.harapErr == nil)
+			}
+		})
+	}
+}
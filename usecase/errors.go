@@ -0,0 +1,119 @@
+package usecase
+
+import "errors"
+
+// ErrorKind mengelompokkan DomainError supaya layer HTTP bisa memetakan status code
+// tanpa bergantung pada isi pesan error
+type ErrorKind int
+
+const (
+	KindValidation ErrorKind = iota + 1
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindBusinessRule
+)
+
+// DomainError adalah error bisnis dengan kode yang stabil dan bisa dibaca mesin.
+// Pesan boleh berubah, kode tidak.
+type DomainError struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	// Fields berisi pesan error per field input, diisi hanya untuk error validasi form
+	Fields map[string]string
+}
+
+func (e *DomainError) Error() string {
+	return e.Message
+}
+
+// NewValidationError membuat error validasi input dengan pesan bebas
+func NewValidationError(message string) *DomainError {
+	return &DomainError{Kind: KindValidation, Code: "VALIDATION_ERROR", Message: message}
+}
+
+// NewFieldErrors membuat error validasi yang memuat pesan untuk setiap field yang salah
+func NewFieldErrors(fields map[string]string) *DomainError {
+	return &DomainError{Kind: KindValidation, Code: "VALIDATION_ERROR", Message: "data tidak valid", Fields: fields}
+}
+
+// AsDomainError mengambil DomainError dari rantai error, jika ada
+func AsDomainError(err error) (*DomainError, bool) {
+	var domainErr *DomainError
+	ok := errors.As(err, &domainErr)
+	return domainErr, ok
+}
+
+var (
+	ErrInvalidFormat = &DomainError{Kind: KindValidation, Code: "INVALID_FORMAT", Message: "Format Data Tidak Valid!"}
+
+	ErrNIKTaken         = &DomainError{Kind: KindConflict, Code: "NIK_TAKEN", Message: "nik sudah digunakan"}
+	ErrNoHPTaken        = &DomainError{Kind: KindConflict, Code: "NO_HP_TAKEN", Message: "no hp sudah digunakan"}
+	ErrPasswordTooShort = &DomainError{Kind: KindValidation, Code: "PASSWORD_TOO_SHORT", Message: "password minimal 8 karakter"}
+	ErrNasabahNotFound  = &DomainError{Kind: KindNotFound, Code: "NASABAH_NOT_FOUND", Message: "nasabah tidak ditemukan"}
+
+	ErrKYCAlreadyVerified = &DomainError{Kind: KindConflict, Code: "KYC_ALREADY_VERIFIED", Message: "data KYC sudah terverifikasi"}
+	ErrKYCNotPending      = &DomainError{Kind: KindBusinessRule, Code: "KYC_NOT_PENDING", Message: "data KYC tidak sedang menunggu verifikasi"}
+	ErrKYCLimitExceeded   = &DomainError{Kind: KindBusinessRule, Code: "KYC_LIMIT_EXCEEDED", Message: "nominal melebihi batas transaksi untuk tingkat KYC nasabah"}
+
+	ErrInvalidNoRekening    = &DomainError{Kind: KindValidation, Code: "INVALID_NO_REKENING", Message: "format nomor rekening tidak valid"}
+	ErrAccountNotFound      = &DomainError{Kind: KindNotFound, Code: "ACCOUNT_NOT_FOUND", Message: "rekening tidak ditemukan"}
+	ErrDestinationNotFound  = &DomainError{Kind: KindNotFound, Code: "DESTINATION_ACCOUNT_NOT_FOUND", Message: "rekening tujuan tidak ditemukan"}
+	ErrAccountForbidden     = &DomainError{Kind: KindForbidden, Code: "ACCOUNT_FORBIDDEN", Message: "rekening bukan milik nasabah"}
+	ErrSameAccount          = &DomainError{Kind: KindValidation, Code: "SAME_ACCOUNT", Message: "rekening asal dan tujuan tidak boleh sama"}
+	ErrInsufficientFunds    = &DomainError{Kind: KindBusinessRule, Code: "INSUFFICIENT_FUNDS", Message: "saldo tidak mencukupi"}
+	ErrProdukNotFound       = &DomainError{Kind: KindNotFound, Code: "PRODUCT_NOT_FOUND", Message: "produk rekening tidak ditemukan"}
+	ErrWithdrawalNotAllowed = &DomainError{Kind: KindBusinessRule, Code: "WITHDRAWAL_NOT_ALLOWED", Message: "produk rekening tidak mengizinkan penarikan"}
+	ErrBelowMinimumBalance  = &DomainError{Kind: KindBusinessRule, Code: "BELOW_MINIMUM_BALANCE", Message: "saldo akhir di bawah saldo minimum produk"}
+	ErrNasabahForbidden     = &DomainError{Kind: KindForbidden, Code: "NASABAH_FORBIDDEN", Message: "akses ke data nasabah lain ditolak"}
+	ErrAccountDormant       = &DomainError{Kind: KindBusinessRule, Code: "ACCOUNT_DORMANT", Message: "rekening dormant tidak bisa didebit"}
+	ErrAccountFrozen        = &DomainError{Kind: KindBusinessRule, Code: "ACCOUNT_FROZEN", Message: "rekening dibekukan tidak bisa didebit"}
+	ErrAccountClosed        = &DomainError{Kind: KindBusinessRule, Code: "ACCOUNT_CLOSED", Message: "rekening sudah ditutup"}
+	ErrInvalidStatus        = &DomainError{Kind: KindValidation, Code: "INVALID_ACCOUNT_STATUS", Message: "status rekening tidak dikenal"}
+	ErrStatusTransition     = &DomainError{Kind: KindBusinessRule, Code: "INVALID_STATUS_TRANSITION", Message: "perubahan status rekening tidak diizinkan"}
+	ErrNonZeroBalance       = &DomainError{Kind: KindBusinessRule, Code: "NON_ZERO_BALANCE", Message: "saldo harus 0 atau dicairkan sebelum rekening ditutup"}
+	ErrLimitPerTransaksi    = &DomainError{Kind: KindBusinessRule, Code: "LIMIT_PER_TRANSACTION_EXCEEDED", Message: "nominal melebihi batas per transaksi"}
+	ErrLimitHarian          = &DomainError{Kind: KindBusinessRule, Code: "DAILY_LIMIT_EXCEEDED", Message: "total transaksi hari ini melebihi batas harian"}
+	ErrLimitJumlahHarian    = &DomainError{Kind: KindBusinessRule, Code: "DAILY_COUNT_EXCEEDED", Message: "jumlah transaksi hari ini melebihi batas harian"}
+	ErrNominalNotWhole      = &DomainError{Kind: KindValidation, Code: "NOMINAL_NOT_WHOLE", Message: "nominal harus bilangan bulat"}
+	ErrNominalNotPositive   = &DomainError{Kind: KindValidation, Code: "NOMINAL_NOT_POSITIVE", Message: "nominal harus lebih dari 0"}
+
+	ErrTransaksiNotFound       = &DomainError{Kind: KindNotFound, Code: "TRANSACTION_NOT_FOUND", Message: "transaksi tidak ditemukan"}
+	ErrInvalidKodeAlasan       = &DomainError{Kind: KindValidation, Code: "INVALID_REASON_CODE", Message: "kode alasan koreksi tidak dikenal"}
+	ErrReverseKoreksi          = &DomainError{Kind: KindBusinessRule, Code: "CANNOT_REVERSE_CORRECTION", Message: "transaksi koreksi tidak bisa dikoreksi lagi"}
+	ErrReversalNegativeBalance = &DomainError{Kind: KindBusinessRule, Code: "REVERSAL_NEGATIVE_BALANCE", Message: "koreksi membuat saldo negatif, gunakan override jika disengaja"}
+	ErrReversalSebagian        = &DomainError{Kind: KindConflict, Code: "REVERSAL_PARTIAL", Message: "sebagian transaksi dengan no referensi ini sudah dikoreksi, periksa koreksi yang ada secara manual"}
+
+	ErrRekonsiliasiNotFound = &DomainError{Kind: KindNotFound, Code: "RECONCILIATION_NOT_FOUND", Message: "rekonsiliasi tidak ditemukan"}
+	ErrRekonsiliasiBerjalan = &DomainError{Kind: KindConflict, Code: "RECONCILIATION_RUNNING", Message: "rekonsiliasi lain sedang berjalan"}
+
+	ErrTutupHariNotFound       = &DomainError{Kind: KindNotFound, Code: "BUSINESS_DATE_NOT_FOUND", Message: "tutup hari untuk tanggal tersebut tidak ditemukan"}
+	ErrTutupHariBelumBerakhir  = &DomainError{Kind: KindBusinessRule, Code: "BUSINESS_DATE_NOT_ENDED", Message: "tanggal buku belum berakhir, tutup hari hanya untuk tanggal sebelum hari ini"}
+	ErrTutupHariTidakBerurutan = &DomainError{Kind: KindBusinessRule, Code: "BUSINESS_DATE_OUT_OF_ORDER", Message: "tutup hari harus berurutan mulai dari tanggal setelah tanggal terakhir yang ditutup"}
+
+	ErrAkruBelumTutupHari    = &DomainError{Kind: KindBusinessRule, Code: "BUSINESS_DATE_NOT_CLOSED", Message: "bunga hanya bisa diakru untuk tanggal buku yang sudah selesai ditutup"}
+	ErrPeriodeBungaBerjalan  = &DomainError{Kind: KindBusinessRule, Code: "INTEREST_PERIOD_NOT_ENDED", Message: "bunga hanya bisa dikreditkan setelah periode bulan berakhir"}
+	ErrPeriodeBungaBelumAkru = &DomainError{Kind: KindBusinessRule, Code: "INTEREST_PERIOD_NOT_ACCRUED", Message: "bunga periode ini belum selesai diakru sampai akhir bulan"}
+	ErrPeriodeBiayaBerjalan  = &DomainError{Kind: KindBusinessRule, Code: "FEE_PERIOD_NOT_ENDED", Message: "biaya admin hanya bisa ditagih setelah periode bulan berakhir"}
+	ErrTunggakanBelumLunas   = &DomainError{Kind: KindBusinessRule, Code: "FEE_ARREARS_OUTSTANDING", Message: "saldo tidak cukup melunasi tunggakan biaya, rekening belum bisa ditutup"}
+
+	ErrInvalidSort           = &DomainError{Kind: KindValidation, Code: "INVALID_SORT", Message: "sort harus asc atau desc"}
+	ErrUnknownJenisTransaksi = &DomainError{Kind: KindValidation, Code: "UNKNOWN_JENIS_TRANSAKSI", Message: "jenis transaksi tidak dikenal"}
+	ErrInvalidDateRange      = &DomainError{Kind: KindValidation, Code: "INVALID_DATE_RANGE", Message: "rentang tanggal tidak valid"}
+
+	ErrInvalidCredentials = &DomainError{Kind: KindUnauthorized, Code: "INVALID_CREDENTIALS", Message: "no hp atau password salah"}
+	ErrUnauthenticated    = &DomainError{Kind: KindUnauthorized, Code: "UNAUTHENTICATED", Message: "token tidak valid"}
+	ErrWrongPassword      = &DomainError{Kind: KindForbidden, Code: "WRONG_PASSWORD", Message: "password salah"}
+	ErrPinRequired        = &DomainError{Kind: KindValidation, Code: "PIN_REQUIRED", Message: "pin wajib diisi"}
+	ErrInvalidPinFormat   = &DomainError{Kind: KindValidation, Code: "INVALID_PIN_FORMAT", Message: "pin harus 6 digit angka"}
+	ErrWrongPin           = &DomainError{Kind: KindForbidden, Code: "WRONG_PIN", Message: "pin salah"}
+	ErrPinLocked          = &DomainError{Kind: KindForbidden, Code: "PIN_LOCKED", Message: "pin terkunci, silakan reset pin"}
+
+	ErrIdempotencyKeyReused     = &DomainError{Kind: KindBusinessRule, Code: "IDEMPOTENCY_KEY_REUSED", Message: "Idempotency-Key sudah dipakai untuk permintaan yang berbeda"}
+	ErrIdempotencyKeyInProgress = &DomainError{Kind: KindConflict, Code: "IDEMPOTENCY_KEY_IN_PROGRESS", Message: "permintaan dengan Idempotency-Key ini sedang diproses"}
+
+	ErrAdminUnauthenticated = &DomainError{Kind: KindUnauthorized, Code: "ADMIN_UNAUTHENTICATED", Message: "kunci admin tidak valid"}
+	ErrOperatorRequired     = &DomainError{Kind: KindValidation, Code: "OPERATOR_REQUIRED", Message: "header X-Operator wajib diisi"}
+)
@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+)
+
+// jamTetap adalah utils.Clock yang selalu mengembalikan waktu yang sama
+type jamTetap time.Time
+
+func (j jamTetap) Now() time.Time {
+	return time.Time(j)
+}
+
+// fake repository di bawah hanya mengimplementasikan method yang dipakai test usecase.
+// Method lain jatuh ke interface yang di-embed dan panic jika terpanggil.
+
+type fakeUnitOfWork struct {
+	repos repository.Repositories
+}
+
+func (f *fakeUnitOfWork) Do(fn func(repos repository.Repositories) error) error {
+	return fn(f.repos)
+}
+
+type fakeRekeningRepository struct {
+	repository.RekeningRepository
+	rekening map[string]model.Rekening
+}
+
+func (f *fakeRekeningRepository) FindByNoREKForUpdate(noREK string) (model.Rekening, error) {
+	return f.rekening[noREK], nil
+}
+
+func (f *fakeRekeningRepository) UpdateSaldo(rekening model.Rekening) (model.Rekening, error) {
+	f.rekening[rekening.NoRekening] = rekening
+	return rekening, nil
+}
+
+type fakeTutupHariRepository struct {
+	repository.TutupHariRepository
+	saldoAkhir map[int]model.Money
+}
+
+func (f *fakeTutupHariRepository) FindSaldoHarianTerakhir(rekeningID int, dari, sampai time.Time) (model.SaldoHarian, error) {
+	return model.SaldoHarian{RekeningID: rekeningID, SaldoAkhir: f.saldoAkhir[rekeningID]}, nil
+}
+
+// fakeBukuBesarRepository menyimpan saldo setiap akun sebagai kredit dikurangi debit
+type fakeBukuBesarRepository struct {
+	repository.BukuBesarRepository
+	akun         map[string]int
+	akunRekening map[int]int
+	jurnal       []model.Jurnal
+	saldo        map[int]model.Money
+}
+
+func (f *fakeBukuBesarRepository) FindAkunByKode(kode string) (model.AkunBuku, error) {
+	return model.AkunBuku{ID: f.akun[kode], Kode: kode}, nil
+}
+
+func (f *fakeBukuBesarRepository) FindAkunByRekeningID(rekeningID int) (model.AkunBuku, error) {
+	return model.AkunBuku{ID: f.akunRekening[rekeningID]}, nil
+}
+
+func (f *fakeBukuBesarRepository) CreateJurnal(jurnal model.Jurnal) (model.Jurnal, error) {
+	jurnal.ID = len(f.jurnal) + 1
+	for _, p := range jurnal.Posting {
+		f.saldo[p.AkunBukuID] += p.Kredit - p.Debit
+	}
+	f.jurnal = append(f.jurnal, jurnal)
+	return jurnal, nil
+}
+
+func (f *fakeBukuBesarRepository) SaldoKewajiban(akunBukuID int) (model.Money, error) {
+	return f.saldo[akunBukuID], nil
+}
+
+type fakeTransaksiRepository struct {
+	repository.TransaksiRepository
+	transaksi   []model.Transaksi
+	rekap       model.RekapHarian
+	rekapDari   time.Time
+	rekapSampai time.Time
+}
+
+func (f *fakeTransaksiRepository) Create(transaksi model.Transaksi) (model.Transaksi, error) {
+	transaksi.ID = len(f.transaksi) + 1
+	f.transaksi = append(f.transaksi, transaksi)
+	return transaksi, nil
+}
+
+func (f *fakeTransaksiRepository) RekapHarian(rekeningID int, jenis []string, dari, sampai time.Time) (model.RekapHarian, error) {
+	f.rekapDari, f.rekapSampai = dari, sampai
+	return f.rekap, nil
+}
+
+func (f *fakeTransaksiRepository) FindByID(id int) (model.Transaksi, error) {
+	for _, t := range f.transaksi {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+	return model.Transaksi{}, nil
+}
+
+func (f *fakeTransaksiRepository) FindByNoReferensi(noReferensi string) ([]model.Transaksi, error) {
+	var hasil []model.Transaksi
+	for _, t := range f.transaksi {
+		if t.NoReferensi == noReferensi {
+			hasil = append(hasil, t)
+		}
+	}
+	return hasil, nil
+}
+
+func (f *fakeTransaksiRepository) FindByTransaksiAsalID(transaksiAsalID int) (model.Transaksi, error) {
+	for _, t := range f.transaksi {
+		if t.TransaksiAsalID != nil && *t.TransaksiAsalID == transaksiAsalID {
+			return t, nil
+		}
+	}
+	return model.Transaksi{}, nil
+}
+
+func (f *fakeTransaksiRepository) FindDipicuBelumDikoreksi(transaksiPemicuID int) ([]model.Transaksi, error) {
+	var hasil []model.Transaksi
+	for _, t := range f.transaksi {
+		if (t.JenisTransaksi != model.JenisBiaya && t.JenisTransaksi != model.JenisPajak) || t.TransaksiPemicuID == nil || *t.TransaksiPemicuID != transaksiPemicuID {
+			continue
+		}
+		if koreksi, _ := f.FindByTransaksiAsalID(t.ID); koreksi.ID == 0 {
+			hasil = append(hasil, t)
+		}
+	}
+	return hasil, nil
+}
+
+type fakeAuditRepository struct {
+	repository.AuditRepository
+}
+
+func (fakeAuditRepository) Create(event model.AuditEvent) (model.AuditEvent, error) {
+	return event, nil
+}
+
+type fakeProdukRepository struct {
+	repository.ProdukRepository
+	produk model.Produk
+}
+
+func (f *fakeProdukRepository) FindByKode(kode string) (model.Produk, error) {
+	return f.produk, nil
+}
+
+type fakeBiayaRepository struct {
+	repository.BiayaRepository
+	tagihan map[int]model.TagihanBiaya
+}
+
+func (f *fakeBiayaRepository) CreateTagihan(tagihan model.TagihanBiaya) (model.TagihanBiaya, error) {
+	tagihan.ID = len(f.tagihan) + 1
+	f.tagihan[tagihan.ID] = tagihan
+	return tagihan, nil
+}
+
+func (f *fakeBiayaRepository) UpdateTagihan(tagihan model.TagihanBiaya) error {
+	f.tagihan[tagihan.ID] = tagihan
+	return nil
+}
+
+func (f *fakeBiayaRepository) FindTagihanByID(id int) (model.TagihanBiaya, error) {
+	return f.tagihan[id], nil
+}
+
+func (f *fakeBiayaRepository) FindTunggakan(rekeningID int) ([]model.TagihanBiaya, error) {
+	var hasil []model.TagihanBiaya
+	for id := 1; id <= len(f.tagihan); id++ {
+		if t := f.tagihan[id]; t.RekeningID == rekeningID && t.Status == model.StatusTagihanTunggakan {
+			hasil = append(hasil, t)
+		}
+	}
+	return hasil, nil
+}
+
+func (f *fakeBiayaRepository) FindTagihanByPemicu(transaksiPemicuID int) (model.TagihanBiaya, error) {
+	for _, t := range f.tagihan {
+		if t.TransaksiPemicuID != nil && *t.TransaksiPemicuID == transaksiPemicuID {
+			return t, nil
+		}
+	}
+	return model.TagihanBiaya{}, nil
+}
@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const formatTanggal = "2006-01-02"
+
+// AjukanKYC menyimpan data KYC nasabah dan menandainya pending untuk diverifikasi petugas.
+// Data yang ditolak boleh diajukan ulang, data yang sudah terverifikasi tidak bisa diubah lewat sini.
+func (u *allUsecase) AjukanKYC(nasabahID int, ajuan model.AjukanKYC) (model.Nasabah, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"nasabah_id": nasabahID,
+		"action":     "AjukanKYC",
+		"layer":      "allUsecase",
+	}).Info("menerima pengajuan data KYC")
+
+	tanggalLahir, err := validateKYC(&ajuan)
+	if err != nil {
+		return model.Nasabah{}, err
+	}
+
+	var nasabah model.Nasabah
+	err = u.UnitOfWork.Do(func(repos repository.Repositories) error {
+		var err error
+		nasabah, err = repos.NasabahRepository.FindByIDForUpdate(nasabahID)
+		if err != nil {
+			return err
+		}
+		if nasabah.ID == 0 {
+			return ErrNasabahNotFound
+		}
+		if nasabah.KYC.Status == model.KYCVerified {
+			return ErrKYCAlreadyVerified
+		}
+
+		nik, err := utils.ParseNIK(nasabah.NIK, u.Clock.Now())
+		if err != nil {
+			return NewFieldErrors(map[string]string{"nik": err.Error()})
+		}
+		if !nik.TanggalLahir.Equal(tanggalLahir) {
+			return NewFieldErrors(map[string]string{"tanggal_lahir": "tanggal lahir tidak sesuai dengan NIK"})
+		}
+
+		nasabah.KYC = model.KYC{
+			TanggalLahir:       &tanggalLahir,
+			TempatLahir:        ajuan.TempatLahir,
+			Alamat:             ajuan.Alamat,
+			NamaIbuKandung:     ajuan.NamaIbuKandung,
+			Pekerjaan:          ajuan.Pekerjaan,
+			RentangPenghasilan: ajuan.RentangPenghasilan,
+			Email:              ajuan.Email,
+			NoDokumen:          ajuan.NoDokumen,
+			Status:             model.KYCPending,
+		}
+		if err := repos.NasabahRepository.UpdateKYC(nasabah); err != nil {
+			return err
+		}
+		return recordAudit(repos, nasabah.ID, model.AuditKYCDiajukan, "data KYC diajukan untuk verifikasi")
+	})
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"nasabah_id": nasabahID,
+			"action":     "AjukanKYC",
+			"layer":      "allUsecase",
+		}).Error("Gagal menyimpan pengajuan KYC")
+		return model.Nasabah{}, err
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"nasabah_id": nasabahID,
+		"action":     "AjukanKYC",
+		"layer":      "allUsecase",
+	}).Info("Pengajuan KYC tersimpan, menunggu verifikasi")
+	return nasabah, nil
+}
+
+// VerifikasiKYC menyetujui atau menolak pengajuan KYC yang sedang pending. Penolakan wajib
+// disertai catatan agar nasabah tahu apa yang harus diperbaiki.
+func (u *allUsecase) VerifikasiKYC(nasabahID int, setuju bool, verifikasi model.VerifikasiKYC, operator string) (model.Nasabah, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"nasabah_id": nasabahID,
+		"setuju":     setuju,
+		"operator":   operator,
+		"action":     "VerifikasiKYC",
+		"layer":      "allUsecase",
+	}).Info("menerima permintaan verifikasi KYC")
+
+	catatan := strings.TrimSpace(verifikasi.Catatan)
+	if !setuju && catatan == "" {
+		return model.Nasabah{}, NewFieldErrors(map[string]string{"catatan": "alasan penolakan wajib diisi"})
+	}
+
+	var nasabah model.Nasabah
+	err := u.UnitOfWork.Do(func(repos repository.Repositories) error {
+		var err error
+		nasabah, err = repos.NasabahRepository.FindByIDForUpdate(nasabahID)
+		if err != nil {
+			return err
+		}
+		if nasabah.ID == 0 {
+			return ErrNasabahNotFound
+		}
+		if nasabah.KYC.Status != model.KYCPending {
+			return ErrKYCNotPending
+		}
+
+		now := u.Clock.Now()
+		jenis := model.AuditKYCDisetujui
+		nasabah.KYC.Status = model.KYCVerified
+		if !setuju {
+			jenis = model.AuditKYCDitolak
+			nasabah.KYC.Status = model.KYCRejected
+		}
+		nasabah.KYC.Catatan = catatan
+		nasabah.KYC.DiverifikasiOleh = operator
+		nasabah.KYC.DiverifikasiAt = &now
+
+		if err := repos.NasabahRepository.UpdateKYC(nasabah); err != nil {
+			return err
+		}
+		return recordAudit(repos, nasabah.ID, jenis, fmt.Sprintf("oleh %s: %s", operator, catatan))
+	})
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"nasabah_id": nasabahID,
+			"operator":   operator,
+			"action":     "VerifikasiKYC",
+			"layer":      "allUsecase",
+		}).Error("Gagal memverifikasi KYC")
+		return model.Nasabah{}, err
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"nasabah_id": nasabahID,
+		"kyc_status": nasabah.KYC.Status,
+		"operator":   operator,
+		"action":     "VerifikasiKYC",
+		"layer":      "allUsecase",
+	}).Info("Verifikasi KYC selesai")
+	return nasabah, nil
+}
+
+func (u *allUsecase) AntreanKYC() ([]model.Nasabah, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"action": "AntreanKYC",
+		"layer":  "allUsecase",
+	}).Info("Mengambil antrean verifikasi KYC")
+	return u.NasabahRepository.FindByKYCStatus(model.KYCPending)
+}
+
+// validateKYC memeriksa seluruh field KYC sekaligus dan mengembalikan tanggal lahir yang sudah diparse
+func validateKYC(ajuan *model.AjukanKYC) (time.Time, error) {
+	fields := map[string]string{}
+	wajib := map[string]*string{
+		"tempat_lahir":     &ajuan.TempatLahir,
+		"alamat":           &ajuan.Alamat,
+		"nama_ibu_kandung": &ajuan.NamaIbuKandung,
+		"pekerjaan":        &ajuan.Pekerjaan,
+		"no_dokumen":       &ajuan.NoDokumen,
+	}
+	for field, value := range wajib {
+		*value = strings.TrimSpace(*value)
+		if *value == "" {
+			fields[field] = field + " wajib diisi"
+		}
+	}
+
+	tanggalLahir, err := time.Parse(formatTanggal, ajuan.TanggalLahir)
+	if err != nil {
+		fields["tanggal_lahir"] = "tanggal lahir harus berformat YYYY-MM-DD"
+	}
+
+	if !model.IsRentangPenghasilan(ajuan.RentangPenghasilan) {
+		fields["rentang_penghasilan"] = "rentang penghasilan tidak dikenal"
+	}
+
+	email, err := mail.ParseAddress(ajuan.Email)
+	if err != nil || email.Name != "" {
+		fields["email"] = "format email tidak valid"
+	} else {
+		ajuan.Email = strings.ToLower(email.Address)
+	}
+
+	if len(fields) > 0 {
+		utils.Log.WithFields(logrus.Fields{
+			"fields": fields,
+			"action": "validateKYC",
+			"layer":  "allUsecase",
+		}).Warn("Data KYC tidak valid")
+		return time.Time{}, NewFieldErrors(fields)
+	}
+	return tanggalLahir, nil
+}
@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// checkLimit menerapkan semua aturan batas transaksi yang cocok dengan produk rekening dan
+// tier KYC pemiliknya. Harus dipanggil di dalam UnitOfWork setelah rekening dikunci, sehingga
+// pemakaian harian yang dihitung tidak berubah sampai saldo selesai diperbarui. Pemakaian
+// harian dihitung pada tanggal buku dari now, diambil dari Clock pemanggil.
+func checkLimit(repos repository.Repositories, rekening model.Rekening, arah string, nominal model.Money, now time.Time) error {
+	nasabah, err := repos.NasabahRepository.FindByID(rekening.NasabahID)
+	if err != nil {
+		return err
+	}
+
+	limits, err := repos.LimitRepository.FindBerlaku(rekening.KodeProduk, nasabah.KYC.Status, arah)
+	if err != nil {
+		return err
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+
+	fields := logrus.Fields{
+		"no_rekening": rekening.NoRekening,
+		"kode_produk": rekening.KodeProduk,
+		"kyc_status":  nasabah.KYC.Status,
+		"arah":        arah,
+		"nominal":     nominal,
+		"action":      "checkLimit",
+		"layer":       "allUsecase",
+	}
+
+	for _, limit := range limits {
+		if limit.MaksPerTransaksi != nil && nominal > *limit.MaksPerTransaksi {
+			if limit.KodeProduk == nil && limit.KYCStatus != nil {
+				// aturan untuk semua produk pada satu tier adalah plafon tier KYC
+				utils.Log.WithFields(fields).WithField("limit_id", limit.ID).Warn("Nominal melebihi batas transaksi tier KYC")
+				return ErrKYCLimitExceeded
+			}
+			utils.Log.WithFields(fields).WithField("limit_id", limit.ID).Warn("Nominal melebihi batas per transaksi")
+			return ErrLimitPerTransaksi
+		}
+	}
+
+	hariIni := awalHari(now)
+	rekap, err := repos.TransaksiRepository.RekapHarian(rekening.ID, model.JenisTransaksiArah(arah), hariIni, hariIni.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
+	for _, limit := range limits {
+		if limit.MaksHarian != nil && rekap.Total+nominal > *limit.MaksHarian {
+			utils.Log.WithFields(fields).WithField("limit_id", limit.ID).WithField("total_hari_ini", rekap.Total).Warn("Total transaksi harian melebihi batas")
+			return ErrLimitHarian
+		}
+		if limit.MaksJumlahHarian != nil && rekap.Jumlah+1 > *limit.MaksJumlahHarian {
+			utils.Log.WithFields(fields).WithField("limit_id", limit.ID).WithField("jumlah_hari_ini", rekap.Jumlah).Warn("Jumlah transaksi harian melebihi batas")
+			return ErrLimitJumlahHarian
+		}
+	}
+	return nil
+}
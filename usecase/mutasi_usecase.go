@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMutasiLimit = 20
+	maxMutasiLimit     = 100
+)
+
+func (u *allUsecase) Mutasi(filter model.MutasiFilter) (model.Mutasi, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"no_rekening":     filter.NoRekening,
+		"jenis_transaksi": filter.JenisTransaksi,
+		"sort":            filter.Sort,
+		"page":            filter.Page,
+		"limit":           filter.Limit,
+		"action":          "Mutasi",
+		"layer":           "allUsecase",
+	}).Info("menerima permintaan mutasi rekening")
+
+	if err := validateNoRek(filter.NoRekening); err != nil {
+		return model.Mutasi{}, err
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultMutasiLimit
+	}
+	if filter.Limit > maxMutasiLimit {
+		filter.Limit = maxMutasiLimit
+	}
+	if filter.Sort == "" {
+		filter.Sort = model.SortDesc
+	}
+	if filter.Sort != model.SortAsc && filter.Sort != model.SortDesc {
+		return model.Mutasi{}, ErrInvalidSort
+	}
+	if filter.JenisTransaksi != "" && !model.IsJenisTransaksi(filter.JenisTransaksi) {
+		return model.Mutasi{}, ErrUnknownJenisTransaksi
+	}
+	if filter.Dari != nil && filter.Sampai != nil && !filter.Dari.Before(*filter.Sampai) {
+		return model.Mutasi{}, ErrInvalidDateRange
+	}
+
+	rekening, err := u.RekeningRepository.FindByNoREK(filter.NoRekening)
+	if err != nil {
+		return model.Mutasi{}, err
+	}
+	if rekening.ID == 0 {
+		utils.Log.WithFields(logrus.Fields{
+			"no_rekening": filter.NoRekening,
+			"action":      "FindByNoREK",
+			"layer":       "allUsecase",
+		}).Warn("Rekening tidak ditemukan")
+		return model.Mutasi{}, ErrAccountNotFound
+	}
+	filter.RekeningID = rekening.ID
+
+	transaksi, total, err := u.TransaksiRepository.FindMutasi(filter)
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"no_rekening": filter.NoRekening,
+			"action":      "FindMutasi",
+			"layer":       "allUsecase",
+		}).Error("Gagal mengambil mutasi rekening")
+		return model.Mutasi{}, err
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"no_rekening": filter.NoRekening,
+		"total":       total,
+		"action":      "Mutasi",
+		"layer":       "allUsecase",
+	}).Info("Berhasil mengambil mutasi rekening")
+	return model.Mutasi{
+		NoRekening: rekening.NoRekening,
+		Page:       filter.Page,
+		Limit:      filter.Limit,
+		Total:      total,
+		Transaksi:  transaksi,
+	}, nil
+}
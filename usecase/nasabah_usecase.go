@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+func (u *allUsecase) FindNasabahByID(nasabahID int) (model.Nasabah, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"nasabah_id": nasabahID,
+		"action":     "FindNasabahByID",
+		"layer":      "allUsecase",
+	}).Info("Mencari profil nasabah")
+	nasabah, err := u.NasabahRepository.FindByID(nasabahID)
+	if err != nil {
+		return model.Nasabah{}, err
+	}
+	if nasabah.ID == 0 {
+		return model.Nasabah{}, ErrNasabahNotFound
+	}
+	return nasabah, nil
+}
+
+// FindNasabahByNIK dipakai petugas back-office untuk mencari nasabah dari KTP
+func (u *allUsecase) FindNasabahByNIK(nik string) (model.Nasabah, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"nik":    nik,
+		"action": "FindNasabahByNIK",
+		"layer":  "allUsecase",
+	}).Info("Mencari nasabah berdasarkan NIK")
+	if len(nik) != utils.NIKLength || !isDigits(nik) {
+		return model.Nasabah{}, NewFieldErrors(map[string]string{"nik": utils.ErrNIKFormat.Error()})
+	}
+	nasabah, err := u.NasabahRepository.FindByNIK(nik)
+	if err != nil {
+		return model.Nasabah{}, err
+	}
+	if nasabah.ID == 0 {
+		return model.Nasabah{}, ErrNasabahNotFound
+	}
+	return nasabah, nil
+}
+
+// UbahProfil mengubah nama dan/atau no hp nasabah. Setiap field yang benar-benar berubah
+// dicatat di riwayat nasabah bersama diubahOleh di dalam transaksi database yang sama.
+func (u *allUsecase) UbahProfil(nasabahID int, ubah model.UbahProfil, diubahOleh string) (model.Nasabah, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"nasabah_id":  nasabahID,
+		"diubah_oleh": diubahOleh,
+		"action":      "UbahProfil",
+		"layer":       "allUsecase",
+	}).Info("menerima permintaan perubahan profil nasabah")
+
+	fields := map[string]string{}
+	if ubah.Nama != nil {
+		nama := strings.TrimSpace(*ubah.Nama)
+		if nama == "" {
+			fields["nama"] = "nama wajib diisi"
+		}
+		ubah.Nama = &nama
+	}
+	if ubah.NoHP != nil {
+		noHP, err := utils.NormalizeNoHP(*ubah.NoHP)
+		if err != nil {
+			fields["no_hp"] = err.Error()
+		}
+		ubah.NoHP = &noHP
+	}
+	if len(fields) > 0 {
+		return model.Nasabah{}, NewFieldErrors(fields)
+	}
+
+	var nasabah model.Nasabah
+	err := u.UnitOfWork.Do(func(repos repository.Repositories) error {
+		var err error
+		nasabah, err = repos.NasabahRepository.FindByIDForUpdate(nasabahID)
+		if err != nil {
+			return err
+		}
+		if nasabah.ID == 0 {
+			return ErrNasabahNotFound
+		}
+
+		var riwayat []model.NasabahRiwayat
+		if ubah.Nama != nil && *ubah.Nama != nasabah.Nama {
+			riwayat = append(riwayat, model.NasabahRiwayat{Field: "nama", NilaiLama: nasabah.Nama, NilaiBaru: *ubah.Nama})
+			nasabah.Nama = *ubah.Nama
+		}
+		if ubah.NoHP != nil && *ubah.NoHP != nasabah.NoHP {
+			pemilik, err := repos.NasabahRepository.FindByNoHP(*ubah.NoHP)
+			if err != nil {
+				return err
+			}
+			if pemilik.ID != 0 {
+				utils.Log.WithFields(logrus.Fields{
+					"nasabah_id": nasabahID,
+					"no_hp":      *ubah.NoHP,
+					"action":     "UbahProfil",
+					"layer":      "allUsecase",
+				}).Warn("no hp sudah digunakan")
+				return ErrNoHPTaken
+			}
+			riwayat = append(riwayat, model.NasabahRiwayat{Field: "no_hp", NilaiLama: nasabah.NoHP, NilaiBaru: *ubah.NoHP})
+			nasabah.NoHP = *ubah.NoHP
+		}
+		if len(riwayat) == 0 {
+			return nil
+		}
+
+		if nasabah, err = repos.NasabahRepository.Update(nasabah); err != nil {
+			return err
+		}
+		for _, r := range riwayat {
+			r.NasabahID = nasabah.ID
+			r.DiubahOleh = diubahOleh
+			if _, err := repos.NasabahRiwayatRepository.Create(r); err != nil {
+				return err
+			}
+			keterangan := fmt.Sprintf("%s diubah dari %q menjadi %q oleh %s", r.Field, r.NilaiLama, r.NilaiBaru, diubahOleh)
+			if err := recordAudit(repos, nasabah.ID, model.AuditProfilDiubah, keterangan); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		err = translateUniqueViolation(err)
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"nasabah_id": nasabahID,
+			"action":     "UbahProfil",
+			"layer":      "allUsecase",
+		}).Error("Gagal mengubah profil nasabah")
+		return model.Nasabah{}, err
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"nasabah_id":  nasabahID,
+		"diubah_oleh": diubahOleh,
+		"action":      "UbahProfil",
+		"layer":       "allUsecase",
+	}).Info("Profil nasabah berhasil diubah")
+	return nasabah, nil
+}
+
+func (u *allUsecase) RiwayatNasabah(nasabahID int) ([]model.NasabahRiwayat, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"nasabah_id": nasabahID,
+		"action":     "RiwayatNasabah",
+		"layer":      "allUsecase",
+	}).Info("Mengambil riwayat perubahan profil nasabah")
+	return u.NasabahRiwayatRepository.FindByNasabahID(nasabahID)
+}
@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// onboard memeriksa keunikan NIK dan No HP lalu membuat nasabah beserta rekening pertamanya.
+// Harus dipanggil di dalam UnitOfWork. Pengecekan di sini hanya untuk pesan yang rapi,
+// constraint unique di database tetap menjadi penjaga terakhir saat ada pendaftaran paralel.
+func (u *allUsecase) onboard(repos repository.Repositories, newNasabah model.Nasabah) (model.Nasabah, model.Rekening, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"nik":    newNasabah.NIK,
+		"action": "FindByNIK",
+		"layer":  "allUsecase",
+	}).Info("Memeriksa ketersediaan nik")
+	findNIK, err := repos.NasabahRepository.FindByNIK(newNasabah.NIK)
+	if err != nil {
+		return model.Nasabah{}, model.Rekening{}, err
+	}
+	if findNIK.ID != 0 {
+		utils.Log.WithFields(logrus.Fields{
+			"nik":    newNasabah.NIK,
+			"action": "validasi",
+			"layer":  "allUsecase",
+		}).Warn("nik sudah digunakan")
+		return model.Nasabah{}, model.Rekening{}, ErrNIKTaken
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"no_hp":  newNasabah.NoHP,
+		"action": "FindByNoHP",
+		"layer":  "allUsecase",
+	}).Info("Memeriksa ketersediaan No HP")
+	findNoHP, err := repos.NasabahRepository.FindByNoHP(newNasabah.NoHP)
+	if err != nil {
+		return model.Nasabah{}, model.Rekening{}, err
+	}
+	if findNoHP.ID != 0 {
+		utils.Log.WithFields(logrus.Fields{
+			"no_hp":  newNasabah.NoHP,
+			"action": "validasi",
+			"layer":  "allUsecase",
+		}).Warn("no hp sudah digunakan")
+		return model.Nasabah{}, model.Rekening{}, ErrNoHPTaken
+	}
+
+	createdNasabah, err := repos.NasabahRepository.Create(newNasabah)
+	if err != nil {
+		return model.Nasabah{}, model.Rekening{}, err
+	}
+	if err := recordAudit(repos, createdNasabah.ID, model.AuditNasabahDibuat, "nasabah baru terdaftar dengan nik "+createdNasabah.NIK); err != nil {
+		return model.Nasabah{}, model.Rekening{}, err
+	}
+
+	rekening, err := u.createRekening(repos, createdNasabah.ID, model.ProdukTabungan)
+	if err != nil {
+		return model.Nasabah{}, model.Rekening{}, err
+	}
+	return createdNasabah, rekening, nil
+}
+
+// translateUniqueViolation memetakan pelanggaran constraint unique dari database ke error domain
+func translateUniqueViolation(err error) error {
+	switch {
+	case repository.IsDuplicate(err, repository.ConstraintNasabahNIK):
+		return ErrNIKTaken
+	case repository.IsDuplicate(err, repository.ConstraintNasabahNoHP):
+		return ErrNoHPTaken
+	}
+	return err
+}
+
+// minUmurNasabah adalah umur minimum pembukaan rekening atas nama sendiri, sama dengan umur wajib KTP
+const minUmurNasabah = 17
+
+// validateNasabah memeriksa seluruh field pendaftaran sekaligus dan mengembalikan pesan per field.
+// No HP dinormalkan ke format E.164 di tempat sehingga pengecekan keunikan memakai nilai kanonik.
+func validateNasabah(nasabah *model.Nasabah, now time.Time) error {
+	fields := map[string]string{}
+
+	if strings.TrimSpace(nasabah.Nama) == "" {
+		fields["nama"] = "nama wajib diisi"
+	}
+
+	if nasabah.NIK == "" {
+		fields["nik"] = "nik wajib diisi"
+	} else if nik, err := utils.ParseNIK(nasabah.NIK, now); err != nil {
+		fields["nik"] = err.Error()
+	} else if nik.Umur(now) < minUmurNasabah {
+		fields["nik"] = fmt.Sprintf("umur nasabah minimal %d tahun", minUmurNasabah)
+	} else {
+		nasabah.KYC.TanggalLahir = &nik.TanggalLahir
+	}
+
+	if nasabah.NoHP == "" {
+		fields["no_hp"] = "no hp wajib diisi"
+	} else if noHP, err := utils.NormalizeNoHP(nasabah.NoHP); err != nil {
+		fields["no_hp"] = err.Error()
+	} else {
+		nasabah.NoHP = noHP
+	}
+
+	if len(nasabah.Password) < minPasswordLength {
+		fields["password"] = ErrPasswordTooShort.Message
+	}
+	if len(nasabah.Pin) != pinLength || !isDigits(nasabah.Pin) {
+		fields["pin"] = ErrInvalidPinFormat.Message
+	}
+
+	if len(fields) > 0 {
+		utils.Log.WithFields(logrus.Fields{
+			"nik":    nasabah.NIK,
+			"fields": fields,
+			"action": "validateNasabah",
+			"layer":  "allUsecase",
+		}).Warn("Data pendaftaran nasabah tidak valid")
+		return NewFieldErrors(fields)
+	}
+	return nil
+}
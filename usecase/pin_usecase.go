@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"fmt"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const pinLength = 6
+
+func (u *allUsecase) UbahPin(nasabahID int, ubah model.UbahPin) error {
+	utils.Log.WithFields(logrus.Fields{
+		"nasabah_id": nasabahID,
+		"action":     "UbahPin",
+		"layer":      "allUsecase",
+	}).Info("menerima permintaan ubah PIN")
+
+	pinHash, err := hashPin(ubah.PinBaru)
+	if err != nil {
+		return err
+	}
+	if err := u.verifyPin(nasabahID, ubah.PinLama); err != nil {
+		return err
+	}
+
+	return u.UnitOfWork.Do(func(repos repository.Repositories) error {
+		nasabah, err := repos.NasabahRepository.FindByIDForUpdate(nasabahID)
+		if err != nil {
+			return err
+		}
+		nasabah.PinHash = pinHash
+		if err := repos.NasabahRepository.UpdatePin(nasabah); err != nil {
+			return err
+		}
+		return recordAudit(repos, nasabahID, model.AuditPinDiubah, "PIN transaksi diubah oleh nasabah")
+	})
+}
+
+// ResetPin mengganti PIN tanpa PIN lama dengan verifikasi password login, sekaligus membuka kunci PIN
+func (u *allUsecase) ResetPin(nasabahID int, reset model.ResetPin) error {
+	utils.Log.WithFields(logrus.Fields{
+		"nasabah_id": nasabahID,
+		"action":     "ResetPin",
+		"layer":      "allUsecase",
+	}).Info("menerima permintaan reset PIN")
+
+	pinHash, err := hashPin(reset.PinBaru)
+	if err != nil {
+		return err
+	}
+
+	return u.UnitOfWork.Do(func(repos repository.Repositories) error {
+		nasabah, err := repos.NasabahRepository.FindByIDForUpdate(nasabahID)
+		if err != nil {
+			return err
+		}
+		if nasabah.ID == 0 {
+			return ErrNasabahNotFound
+		}
+		if bcrypt.CompareHashAndPassword([]byte(nasabah.PasswordHash), []byte(reset.Password)) != nil {
+			utils.Log.WithFields(logrus.Fields{
+				"nasabah_id": nasabahID,
+				"action":     "ResetPin",
+				"layer":      "allUsecase",
+			}).Warn("Password salah saat reset PIN")
+			return ErrWrongPassword
+		}
+
+		nasabah.PinHash = pinHash
+		nasabah.PinGagal = 0
+		nasabah.PinTerkunciAt = nil
+		if err := repos.NasabahRepository.UpdatePin(nasabah); err != nil {
+			return err
+		}
+		return recordAudit(repos, nasabahID, model.AuditPinDireset, "PIN transaksi direset dengan verifikasi password")
+	})
+}
+
+// verifyPin mencocokkan PIN transaksi nasabah. Setiap kegagalan dicatat dan PIN dikunci setelah
+// PinMaxGagal kali gagal berturut-turut. Dijalankan di transaksi database tersendiri supaya
+// penghitung gagal tetap tersimpan walaupun operasi debit yang memanggilnya dibatalkan.
+func (u *allUsecase) verifyPin(nasabahID int, pin string) error {
+	if pin == "" {
+		return ErrPinRequired
+	}
+
+	var verifyErr error
+	err := u.UnitOfWork.Do(func(repos repository.Repositories) error {
+		nasabah, err := repos.NasabahRepository.FindByIDForUpdate(nasabahID)
+		if err != nil {
+			return err
+		}
+		if nasabah.ID == 0 {
+			verifyErr = ErrNasabahNotFound
+			return nil
+		}
+		if nasabah.PinTerkunciAt != nil {
+			verifyErr = ErrPinLocked
+			return nil
+		}
+
+		if bcrypt.CompareHashAndPassword([]byte(nasabah.PinHash), []byte(pin)) == nil {
+			if nasabah.PinGagal == 0 {
+				return nil
+			}
+			nasabah.PinGagal = 0
+			return repos.NasabahRepository.UpdatePin(nasabah)
+		}
+
+		nasabah.PinGagal++
+		verifyErr = ErrWrongPin
+		if err := recordAudit(repos, nasabahID, model.AuditPinGagal, fmt.Sprintf("PIN salah, percobaan ke-%d", nasabah.PinGagal)); err != nil {
+			return err
+		}
+		if nasabah.PinGagal >= u.PinMaxGagal {
+			now := u.Clock.Now()
+			nasabah.PinTerkunciAt = &now
+			verifyErr = ErrPinLocked
+			utils.Log.WithFields(logrus.Fields{
+				"nasabah_id": nasabahID,
+				"pin_gagal":  nasabah.PinGagal,
+				"action":     "verifyPin",
+				"layer":      "allUsecase",
+			}).Warn("PIN dikunci karena terlalu banyak percobaan gagal")
+			if err := recordAudit(repos, nasabahID, model.AuditPinTerkunci, fmt.Sprintf("PIN dikunci setelah %d kali gagal", nasabah.PinGagal)); err != nil {
+				return err
+			}
+		}
+		return repos.NasabahRepository.UpdatePin(nasabah)
+	})
+	if err != nil {
+		return err
+	}
+	return verifyErr
+}
+
+func hashPin(pin string) (string, error) {
+	if len(pin) != pinLength || !isDigits(pin) {
+		return "", ErrInvalidPinFormat
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
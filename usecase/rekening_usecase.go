@@ -0,0 +1,194 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const maxGenerateNoRekAttempts = 5
+
+// createRekening membuat rekening baru dengan nomor unik. Nomor yang bentrok dengan rekening
+// lain dibuat ulang hingga maxGenerateNoRekAttempts kali di dalam transaksi yang sama.
+func (u *allUsecase) createRekening(repos repository.Repositories, nasabahID int, kodeProduk string) (model.Rekening, error) {
+	for attempt := 1; attempt <= maxGenerateNoRekAttempts; attempt++ {
+		noRek, err := u.NoRekGenerator.Generate()
+		if err != nil {
+			return model.Rekening{}, err
+		}
+
+		existing, err := repos.RekeningRepository.FindByNoREK(noRek)
+		if err != nil {
+			return model.Rekening{}, err
+		}
+		if existing.ID != 0 {
+			utils.Log.WithFields(logrus.Fields{
+				"no_rekening": noRek,
+				"percobaan":   attempt,
+				"action":      "createRekening",
+				"layer":       "allUsecase",
+			}).Warn("Nomor rekening bentrok, membuat nomor baru")
+			continue
+		}
+
+		utils.Log.WithFields(logrus.Fields{
+			"nasabah_id":  nasabahID,
+			"no_rekening": noRek,
+			"kode_produk": kodeProduk,
+			"action":      "create",
+			"layer":       "allUsecase",
+		}).Info("Membuat rekening untuk nasabah")
+		rekening, err := repos.RekeningRepository.Create(model.Rekening{
+			NasabahID:  nasabahID,
+			NoRekening: noRek,
+			KodeProduk: kodeProduk,
+		})
+		if err != nil {
+			return model.Rekening{}, err
+		}
+		if err := createAkunRekening(repos, rekening); err != nil {
+			return model.Rekening{}, err
+		}
+		if err := recordAudit(repos, nasabahID, model.AuditRekeningDibuka, fmt.Sprintf("rekening %s produk %s dibuka", noRek, kodeProduk)); err != nil {
+			return model.Rekening{}, err
+		}
+		return rekening, nil
+	}
+	return model.Rekening{}, errors.New("gagal membuat nomor rekening unik")
+}
+
+// BukaRekening membuka rekening tambahan untuk nasabah yang sudah terdaftar
+func (u *allUsecase) BukaRekening(nasabahID int, bukaRekening model.BukaRekening) (model.Rekening, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"nasabah_id":  nasabahID,
+		"kode_produk": bukaRekening.KodeProduk,
+		"action":      "BukaRekening",
+		"layer":       "allUsecase",
+	}).Info("menerima permintaan pembukaan rekening")
+
+	var rekening model.Rekening
+	err := u.UnitOfWork.Do(func(repos repository.Repositories) error {
+		nasabah, err := repos.NasabahRepository.FindByIDForUpdate(nasabahID)
+		if err != nil {
+			return err
+		}
+		if nasabah.ID == 0 {
+			return ErrNasabahNotFound
+		}
+
+		produk, err := repos.ProdukRepository.FindByKode(bukaRekening.KodeProduk)
+		if err != nil {
+			return err
+		}
+		if produk.Kode == "" {
+			return ErrProdukNotFound
+		}
+
+		rekening, err = u.createRekening(repos, nasabahID, produk.Kode)
+		return err
+	})
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"nasabah_id":  nasabahID,
+			"kode_produk": bukaRekening.KodeProduk,
+			"action":      "BukaRekening",
+			"layer":       "allUsecase",
+		}).Error("Gagal membuka rekening")
+		return model.Rekening{}, err
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"nasabah_id":  nasabahID,
+		"no_rekening": rekening.NoRekening,
+		"kode_produk": rekening.KodeProduk,
+		"action":      "BukaRekening",
+		"layer":       "allUsecase",
+	}).Info("Rekening berhasil dibuka")
+	return rekening, nil
+}
+
+func (u *allUsecase) FindAllRekeningByNasabahID(nasabahID int) ([]model.Rekening, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"nasabah_id": nasabahID,
+		"action":     "FindAllRekeningByNasabahID",
+		"layer":      "allUsecase",
+	}).Info("Mencari semua rekening milik nasabah")
+	return u.RekeningRepository.FindAllByNasabahID(nasabahID)
+}
+
+// checkDebit menerapkan status rekening, aturan produk, kecukupan saldo, batas tier KYC dan
+// batas transaksi sebelum rekening didebit. rekening harus sudah dikunci oleh pemanggil, now
+// menentukan hari yang dipakai untuk menghitung pemakaian harian.
+func checkDebit(repos repository.Repositories, rekening model.Rekening, nominal model.Money, now time.Time) error {
+	switch rekening.Status {
+	case model.StatusDormant:
+		return ErrAccountDormant
+	case model.StatusDibekukan:
+		return ErrAccountFrozen
+	case model.StatusDitutup:
+		return ErrAccountClosed
+	}
+
+	produk, err := repos.ProdukRepository.FindByKode(rekening.KodeProduk)
+	if err != nil {
+		return err
+	}
+	if produk.Kode == "" {
+		return ErrProdukNotFound
+	}
+
+	fields := logrus.Fields{
+		"no_rekening":   rekening.NoRekening,
+		"kode_produk":   produk.Kode,
+		"saldo":         rekening.Saldo,
+		"saldo_minimum": produk.SaldoMinimum,
+		"nominal":       nominal,
+		"action":        "checkDebit",
+		"layer":         "allUsecase",
+	}
+	if !produk.BolehTarik {
+		utils.Log.WithFields(fields).Warn("Produk rekening tidak mengizinkan penarikan")
+		return ErrWithdrawalNotAllowed
+	}
+	if rekening.Saldo < nominal {
+		utils.Log.WithFields(fields).Warn("Saldo tidak mencukupi")
+		return ErrInsufficientFunds
+	}
+	if rekening.Saldo-nominal < produk.SaldoMinimum {
+		utils.Log.WithFields(fields).Warn("Saldo akhir di bawah saldo minimum produk")
+		return ErrBelowMinimumBalance
+	}
+	return checkLimit(repos, rekening, model.ArahDebit, nominal, now)
+}
+
+// checkCredit memastikan rekening masih boleh menerima dana. Rekening dormant dan
+// dibekukan tetap bisa dikredit, hanya rekening yang ditutup yang ditolak.
+func checkCredit(rekening model.Rekening) error {
+	if rekening.Status == model.StatusDitutup {
+		utils.Log.WithFields(logrus.Fields{
+			"no_rekening": rekening.NoRekening,
+			"action":      "checkCredit",
+			"layer":       "allUsecase",
+		}).Warn("Rekening sudah ditutup")
+		return ErrAccountClosed
+	}
+	return nil
+}
+
+// validateNoRek menolak nomor rekening yang formatnya salah sebelum menyentuh database
+func validateNoRek(noREK string) error {
+	if !utils.ValidNoRek(noREK) {
+		utils.Log.WithFields(logrus.Fields{
+			"no_rekening": noREK,
+			"action":      "validasi no rekening",
+			"layer":       "allUsecase",
+		}).Warn("Format nomor rekening tidak valid")
+		return ErrInvalidNoRekening
+	}
+	return nil
+}
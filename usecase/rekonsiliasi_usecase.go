@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"fmt"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	rekonsiliasiBatch         = 500
+	rekonsiliasiDaftarTerbaru = 50
+)
+
+// RekonsiliasiUsecase mencocokkan saldo rekening dengan saldo yang dihitung ulang dari
+// transaksi dan dari buku besar. Dipakai oleh subcommand CLI, jadwal dan endpoint admin.
+type RekonsiliasiUsecase interface {
+	Jalankan(pemicu string, bekukan bool) (model.Rekonsiliasi, error)
+	FindTerbaru() ([]model.Rekonsiliasi, error)
+	FindByID(id int) (model.Rekonsiliasi, error)
+}
+
+type rekonsiliasiUsecase struct {
+	RekeningRepository     repository.RekeningRepository
+	RekonsiliasiRepository repository.RekonsiliasiRepository
+	UnitOfWork             repository.UnitOfWork
+	Clock                  utils.Clock
+}
+
+// Jalankan memeriksa semua rekening satu per satu. Setiap rekening diperiksa di transaksi
+// databasenya sendiri dengan baris rekening dikunci, sehingga transaksi yang sedang berjalan
+// tidak terbaca sebagai selisih. Jika bekukan true, rekening yang selisih langsung dibekukan.
+// Hanya satu rekonsiliasi yang boleh berjalan, permintaan lain ditolak dengan
+// ErrRekonsiliasiBerjalan.
+func (u *rekonsiliasiUsecase) Jalankan(pemicu string, bekukan bool) (model.Rekonsiliasi, error) {
+	fields := logrus.Fields{
+		"pemicu":  pemicu,
+		"bekukan": bekukan,
+		"action":  "Rekonsiliasi",
+		"layer":   "rekonsiliasiUsecase",
+	}
+	utils.Log.WithFields(fields).Info("Memulai rekonsiliasi saldo")
+
+	var (
+		run    model.Rekonsiliasi
+		runErr error
+	)
+	dijalankan, err := u.RekonsiliasiRepository.Eksklusif(func() error {
+		run, runErr = u.jalankan(pemicu, bekukan)
+		return nil
+	})
+	if err != nil {
+		return model.Rekonsiliasi{}, err
+	}
+	if !dijalankan {
+		utils.Log.WithFields(fields).Warn("Rekonsiliasi lain sedang berjalan")
+		return model.Rekonsiliasi{}, ErrRekonsiliasiBerjalan
+	}
+	return run, runErr
+}
+
+// jalankan mencatat run lalu memeriksa semua rekening. Dipanggil sambil memegang lock rekonsiliasi.
+func (u *rekonsiliasiUsecase) jalankan(pemicu string, bekukan bool) (model.Rekonsiliasi, error) {
+	run, err := u.RekonsiliasiRepository.Create(model.Rekonsiliasi{
+		Pemicu:  pemicu,
+		Status:  model.StatusRekonsiliasiBerjalan,
+		Bekukan: bekukan,
+		MulaiAt: u.Clock.Now(),
+	})
+	if err != nil {
+		return model.Rekonsiliasi{}, err
+	}
+
+	if err := u.periksaSemua(&run); err != nil {
+		// run tetap ditutup supaya tidak tampak berjalan selamanya
+		run.Status = model.StatusRekonsiliasiGagal
+		run.PesanGagal = err.Error()
+		selesai := u.Clock.Now()
+		run.SelesaiAt = &selesai
+		if selesaiErr := u.RekonsiliasiRepository.Selesai(run); selesaiErr != nil {
+			utils.Log.WithError(selesaiErr).WithFields(logrus.Fields{
+				"rekonsiliasi_id": run.ID,
+				"action":          "Rekonsiliasi",
+				"layer":           "rekonsiliasiUsecase",
+			}).Error("Gagal menandai rekonsiliasi sebagai gagal")
+		}
+		return run, err
+	}
+
+	run.Status = model.StatusRekonsiliasiSelesai
+	selesai := u.Clock.Now()
+	run.SelesaiAt = &selesai
+	if err := u.RekonsiliasiRepository.Selesai(run); err != nil {
+		return run, err
+	}
+
+	fields := logrus.Fields{
+		"rekonsiliasi_id": run.ID,
+		"jumlah_rekening": run.JumlahRekening,
+		"jumlah_selisih":  run.JumlahSelisih,
+		"action":          "Rekonsiliasi",
+		"layer":           "rekonsiliasiUsecase",
+	}
+	if run.JumlahSelisih > 0 {
+		utils.Log.WithFields(fields).Error("Rekonsiliasi menemukan selisih saldo")
+	} else {
+		utils.Log.WithFields(fields).Info("Rekonsiliasi selesai tanpa selisih")
+	}
+	return run, nil
+}
+
+// periksaSemua memeriksa rekening berurutan per halaman dan mencatat jumlahnya di run.
+// Berhenti pada error pertama.
+func (u *rekonsiliasiUsecase) periksaSemua(run *model.Rekonsiliasi) error {
+	lastID := 0
+	for {
+		rekenings, err := u.RekeningRepository.FindAfterID(lastID, rekonsiliasiBatch)
+		if err != nil {
+			return err
+		}
+		for _, rekening := range rekenings {
+			selisih, err := u.periksaRekening(*run, rekening.NoRekening)
+			if err != nil {
+				utils.Log.WithError(err).WithFields(logrus.Fields{
+					"rekonsiliasi_id": run.ID,
+					"no_rekening":     rekening.NoRekening,
+					"action":          "Rekonsiliasi",
+					"layer":           "rekonsiliasiUsecase",
+				}).Error("Rekonsiliasi dihentikan karena gagal memeriksa rekening")
+				return err
+			}
+			run.JumlahRekening++
+			if selisih {
+				run.JumlahSelisih++
+			}
+			lastID = rekening.ID
+		}
+		if len(rekenings) < rekonsiliasiBatch {
+			return nil
+		}
+	}
+}
+
+// periksaRekening mengembalikan true jika saldo rekening tidak cocok dengan transaksi atau buku besar
+func (u *rekonsiliasiUsecase) periksaRekening(run model.Rekonsiliasi, noREK string) (bool, error) {
+	var adaSelisih bool
+	err := u.UnitOfWork.Do(func(repos repository.Repositories) error {
+		rekening, err := repos.RekeningRepository.FindByNoREKForUpdate(noREK)
+		if err != nil {
+			return err
+		}
+
+		saldoTransaksi, err := repos.TransaksiRepository.SaldoDariTransaksi(rekening.ID)
+		if err != nil {
+			return err
+		}
+		akun, err := repos.BukuBesarRepository.FindAkunByRekeningID(rekening.ID)
+		if err != nil {
+			return err
+		}
+		var saldoBukuBesar model.Money
+		if akun.ID != 0 {
+			if saldoBukuBesar, err = repos.BukuBesarRepository.SaldoKewajiban(akun.ID); err != nil {
+				return err
+			}
+		}
+
+		adaSelisih = rekening.Saldo != saldoTransaksi || rekening.Saldo != saldoBukuBesar
+		if !adaSelisih {
+			return nil
+		}
+
+		utils.Log.WithFields(logrus.Fields{
+			"rekonsiliasi_id":  run.ID,
+			"no_rekening":      rekening.NoRekening,
+			"saldo_rekening":   rekening.Saldo,
+			"saldo_transaksi":  saldoTransaksi,
+			"saldo_buku_besar": saldoBukuBesar,
+			"action":           "periksaRekening",
+			"layer":            "rekonsiliasiUsecase",
+		}).Warn("Saldo rekening tidak cocok")
+
+		dibekukan := run.Bekukan && model.CanTransition(rekening.Status, model.StatusDibekukan)
+		if dibekukan {
+			statusLama := rekening.Status
+			rekening.Status = model.StatusDibekukan
+			if err := repos.RekeningRepository.UpdateStatus(rekening); err != nil {
+				return err
+			}
+			keterangan := fmt.Sprintf("rekening %s: %s -> %s oleh rekonsiliasi %d, alasan: selisih saldo", rekening.NoRekening, statusLama, rekening.Status, run.ID)
+			if err := recordAudit(repos, rekening.NasabahID, model.AuditStatusRekening, keterangan); err != nil {
+				return err
+			}
+		}
+
+		_, err = repos.RekonsiliasiRepository.CreateSelisih(model.RekonsiliasiSelisih{
+			RekonsiliasiID: run.ID,
+			RekeningID:     rekening.ID,
+			NoRekening:     rekening.NoRekening,
+			SaldoRekening:  rekening.Saldo,
+			SaldoTransaksi: saldoTransaksi,
+			SaldoBukuBesar: saldoBukuBesar,
+			Dibekukan:      dibekukan,
+		})
+		return err
+	})
+	return adaSelisih, err
+}
+
+func (u *rekonsiliasiUsecase) FindTerbaru() ([]model.Rekonsiliasi, error) {
+	return u.RekonsiliasiRepository.FindTerbaru(rekonsiliasiDaftarTerbaru)
+}
+
+func (u *rekonsiliasiUsecase) FindByID(id int) (model.Rekonsiliasi, error) {
+	rekonsiliasi, err := u.RekonsiliasiRepository.FindByID(id)
+	if err != nil {
+		return model.Rekonsiliasi{}, err
+	}
+	if rekonsiliasi.ID == 0 {
+		return model.Rekonsiliasi{}, ErrRekonsiliasiNotFound
+	}
+	return rekonsiliasi, nil
+}
+
+func NewRekonsiliasiUsecase(rekeningRepository repository.RekeningRepository, rekonsiliasiRepository repository.RekonsiliasiRepository, unitOfWork repository.UnitOfWork, clock utils.Clock) RekonsiliasiUsecase {
+	return &rekonsiliasiUsecase{
+		RekeningRepository:     rekeningRepository,
+		RekonsiliasiRepository: rekonsiliasiRepository,
+		UnitOfWork:             unitOfWork,
+		Clock:                  clock,
+	}
+}
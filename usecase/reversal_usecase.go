@@ -0,0 +1,264 @@
+package usecase
+
+import (
+	"fmt"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// Reversal membatalkan transaksi dengan menulis transaksi koreksi yang menunjuk ke transaksi asal.
+// Transaksi asal tidak pernah diubah atau dihapus. Untuk transfer, kedua sisi dikoreksi sekaligus.
+// Biaya yang dipicu transaksi tersebut ikut dikembalikan ke pendapatan biaya dan tagihannya
+// dibatalkan, begitu juga tagihan dari transaksi biaya yang dikoreksi langsung. Koreksi bunga
+// ikut mengoreksi pajaknya dan membuka kembali akru bunganya untuk dikreditkan ulang.
+// Memanggil ulang untuk transaksi yang sudah dikoreksi mengembalikan koreksi yang sudah ada.
+func (u *allUsecase) Reversal(transaksiID int, reversal model.Reversal, operator string) ([]model.Transaksi, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"transaksi_id": transaksiID,
+		"kode_alasan":  reversal.KodeAlasan,
+		"override":     reversal.Override,
+		"operator":     operator,
+		"action":       "Reversal",
+		"layer":        "allUsecase",
+	}).Info("menerima permintaan koreksi transaksi")
+
+	if !model.IsKodeAlasan(reversal.KodeAlasan) {
+		return nil, ErrInvalidKodeAlasan
+	}
+
+	asal, err := u.TransaksiRepository.FindByID(transaksiID)
+	if err != nil {
+		return nil, err
+	}
+	if asal.ID == 0 {
+		return nil, ErrTransaksiNotFound
+	}
+
+	legs := []model.Transaksi{asal}
+	if asal.NoReferensi != "" {
+		if legs, err = u.TransaksiRepository.FindByNoReferensi(asal.NoReferensi); err != nil {
+			return nil, err
+		}
+	}
+	for _, leg := range legs {
+		if leg.TransaksiAsalID != nil {
+			return nil, ErrReverseKoreksi
+		}
+	}
+
+	noReferensi, err := utils.GenerateNoReferensi("REV")
+	if err != nil {
+		return nil, err
+	}
+
+	var koreksi []model.Transaksi
+	err = u.UnitOfWork.Do(func(repos repository.Repositories) error {
+		koreksi = nil
+		locked, err := lockRekeningLegs(repos, legs)
+		if err != nil {
+			return err
+		}
+
+		for _, leg := range legs {
+			sudah, err := repos.TransaksiRepository.FindByTransaksiAsalID(leg.ID)
+			if err != nil {
+				return err
+			}
+			if sudah.ID != 0 {
+				koreksi = append(koreksi, sudah)
+			}
+		}
+		switch {
+		case len(koreksi) == len(legs):
+			utils.Log.WithFields(logrus.Fields{
+				"transaksi_id": transaksiID,
+				"action":       "Reversal",
+				"layer":        "allUsecase",
+			}).Info("Transaksi sudah pernah dikoreksi, mengembalikan koreksi yang ada")
+			return nil
+		case len(koreksi) > 0:
+			utils.Log.WithFields(logrus.Fields{
+				"transaksi_id": transaksiID,
+				"dikoreksi":    len(koreksi),
+				"jumlah_sisi":  len(legs),
+				"action":       "Reversal",
+				"layer":        "allUsecase",
+			}).Error("Hanya sebagian sisi transaksi yang sudah dikoreksi")
+			return ErrReversalSebagian
+		}
+
+		// biaya dan pajak yang dipicu transaksi ini ikut dikoreksi di jurnal yang sama
+		baris := append([]model.Transaksi{}, legs...)
+		for _, leg := range legs {
+			dipicu, err := repos.TransaksiRepository.FindDipicuBelumDikoreksi(leg.ID)
+			if err != nil {
+				return err
+			}
+			baris = append(baris, dipicu...)
+		}
+
+		// hitung saldo baru setiap baris dulu, lalu bukukan semuanya dalam satu jurnal balik
+		rekenings := make(map[int]*model.Rekening, len(locked))
+		for id, rekening := range locked {
+			if rekening.Status == model.StatusDitutup {
+				return ErrAccountClosed
+			}
+			salinan := rekening
+			rekenings[id] = &salinan
+		}
+		saldoAwal := make([]model.Money, len(baris))
+		saldoAkhir := make([]model.Money, len(baris))
+		jenis := make([]string, len(baris))
+		entri := make([]entriJurnal, 0, len(baris)+len(urutanAkunLawan))
+		// lawan berisi debit bersih tiap akun lawan, negatif berarti kredit
+		lawan := make(map[string]model.Money, len(urutanAkunLawan))
+		for i, leg := range baris {
+			rekening := rekenings[leg.RekeningID]
+			saldoAwal[i] = rekening.Saldo
+			if model.IsDebit(leg.JenisTransaksi) {
+				jenis[i] = model.JenisKoreksiKredit
+				rekening.Saldo += leg.Nominal
+				lawan[akunLawan(leg.JenisTransaksi)] += leg.Nominal
+				entri = append(entri, entriJurnal{Rekening: rekening, Kredit: leg.Nominal})
+			} else {
+				jenis[i] = model.JenisKoreksiDebit
+				rekening.Saldo -= leg.Nominal
+				lawan[akunLawan(leg.JenisTransaksi)] -= leg.Nominal
+				entri = append(entri, entriJurnal{Rekening: rekening, Debit: leg.Nominal})
+			}
+			saldoAkhir[i] = rekening.Saldo
+		}
+		for id, rekening := range rekenings {
+			if rekening.Saldo < 0 && !reversal.Override {
+				utils.Log.WithFields(logrus.Fields{
+					"no_rekening": rekening.NoRekening,
+					"saldo":       locked[id].Saldo,
+					"saldo_akhir": rekening.Saldo,
+					"action":      "Reversal",
+					"layer":       "allUsecase",
+				}).Warn("Koreksi akan membuat saldo negatif")
+				return ErrReversalNegativeBalance
+			}
+		}
+		// kedua sisi transfer saling meniadakan di suspense, sisanya dibukukan ke akun lawan
+		// transaksi asalnya
+		for _, kode := range urutanAkunLawan {
+			switch nominal := lawan[kode]; {
+			case nominal > 0:
+				entri = append(entri, entriJurnal{KodeAkun: kode, Debit: nominal})
+			case nominal < 0:
+				entri = append(entri, entriJurnal{KodeAkun: kode, Kredit: -nominal})
+			}
+		}
+
+		jurnal, err := postJurnal(repos, noReferensi, fmt.Sprintf("koreksi transaksi %d, alasan %s", transaksiID, reversal.KodeAlasan), entri)
+		if err != nil {
+			return err
+		}
+
+		for i, leg := range baris {
+			legID := leg.ID
+			rekening := rekenings[leg.RekeningID]
+			created, err := repos.TransaksiRepository.Create(model.Transaksi{
+				RekeningID:      rekening.ID,
+				JenisTransaksi:  jenis[i],
+				Nominal:         leg.Nominal,
+				SaldoAwal:       saldoAwal[i],
+				SaldoAkhir:      saldoAkhir[i],
+				NoReferensi:     noReferensi,
+				JurnalID:        &jurnal.ID,
+				TransaksiAsalID: &legID,
+				KodeAlasan:      reversal.KodeAlasan,
+				Operator:        operator,
+			})
+			if err != nil {
+				return err
+			}
+			koreksi = append(koreksi, created)
+			if err := recordSaldo(repos, *rekening, created); err != nil {
+				return err
+			}
+
+			keterangan := fmt.Sprintf("transaksi %d (%s %s) dikoreksi oleh %s, alasan %s: %s",
+				leg.ID, leg.JenisTransaksi, leg.Nominal, operator, reversal.KodeAlasan, reversal.Keterangan)
+			if err := recordAudit(repos, rekening.NasabahID, model.AuditReversal, keterangan); err != nil {
+				return err
+			}
+		}
+		// akru bunga yang dikoreksi dibuka lagi agar periode itu bisa dikreditkan ulang
+		for _, leg := range baris {
+			if leg.JenisTransaksi == model.JenisBunga {
+				if err := repos.BungaRepository.BatalkanKredit(leg.ID); err != nil {
+					return err
+				}
+			}
+		}
+		return batalkanTagihanBiaya(repos, legs, baris)
+	})
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"transaksi_id": transaksiID,
+			"operator":     operator,
+			"action":       "Reversal",
+			"layer":        "allUsecase",
+		}).Error("Koreksi transaksi gagal, seluruh perubahan di-rollback")
+		return nil, err
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"transaksi_id": transaksiID,
+		"operator":     operator,
+		"action":       "Reversal",
+		"layer":        "allUsecase",
+	}).Info("Koreksi transaksi berhasil")
+	return koreksi, nil
+}
+
+// urutanAkunLawan adalah urutan akun lawan pada jurnal koreksi agar posting selalu sama
+var urutanAkunLawan = []string{model.AkunSuspense, model.AkunPendapatanBiaya, model.AkunBebanBunga, model.AkunUtangPajak}
+
+// akunLawan mengembalikan akun yang dibukukan berlawanan dengan rekening saat transaksi
+// berjenis tersebut terjadi. Tabung dan tarik lawannya kas, tetapi koreksinya ditampung di
+// suspense sampai selisih kas diselesaikan.
+func akunLawan(jenisTransaksi string) string {
+	switch jenisTransaksi {
+	case model.JenisBiaya:
+		return model.AkunPendapatanBiaya
+	case model.JenisBunga:
+		return model.AkunBebanBunga
+	case model.JenisPajak:
+		return model.AkunUtangPajak
+	}
+	return model.AkunSuspense
+}
+
+// lockRekeningLegs mengunci rekening dari setiap baris transaksi dan mengembalikannya per ID rekening.
+// Dua rekening transfer dikunci lewat lockRekeningPair agar urutannya sama dengan Transfer.
+func lockRekeningLegs(repos repository.Repositories, legs []model.Transaksi) (map[int]model.Rekening, error) {
+	locked := make(map[int]model.Rekening, len(legs))
+	switch len(legs) {
+	case 1:
+		rekening, err := repos.RekeningRepository.FindByNoREKForUpdate(legs[0].Rekening.NoRekening)
+		if err != nil {
+			return nil, err
+		}
+		locked[rekening.ID] = rekening
+	case 2:
+		a, b, err := lockRekeningPair(repos, legs[0].Rekening.NoRekening, legs[1].Rekening.NoRekening)
+		if err != nil {
+			return nil, err
+		}
+		locked[a.ID], locked[b.ID] = a, b
+	default:
+		return nil, fmt.Errorf("transaksi dengan no referensi yang sama berjumlah %d, tidak bisa dikoreksi", len(legs))
+	}
+	for _, leg := range legs {
+		if _, ok := locked[leg.RekeningID]; !ok {
+			return nil, ErrAccountNotFound
+		}
+	}
+	return locked, nil
+}
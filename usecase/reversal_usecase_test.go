@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+)
+
+const (
+	tarikUjiID   = 1
+	biayaUjiID   = 2
+	tagihanUjiID = 1
+)
+
+// siapkanKoreksiBiaya menyusun rekening yang sudah ditarik Rp100.000 dan dikenai biaya tarik
+// Rp5.000, dengan tertagih bagian biaya yang sudah terpotong dari saldo
+func siapkanKoreksiBiaya(tertagih model.Money) (*allUsecase, repository.Repositories) {
+	nominalBiaya := model.Rupiah(5_000)
+	rekening := model.Rekening{
+		ID:         rekeningBiayaID,
+		NasabahID:  5,
+		NoRekening: "1000000002",
+		Saldo:      model.Rupiah(900_000) - tertagih,
+		Status:     model.StatusAktif,
+	}
+	tarikID, tagihanID := tarikUjiID, tagihanUjiID
+	transaksi := []model.Transaksi{
+		{ID: tarikID, RekeningID: rekening.ID, JenisTransaksi: model.JenisTarik, Nominal: model.Rupiah(100_000), Rekening: rekening},
+	}
+	status := model.StatusTagihanTunggakan
+	if tertagih > 0 {
+		transaksi = append(transaksi, model.Transaksi{
+			ID:                biayaUjiID,
+			RekeningID:        rekening.ID,
+			JenisTransaksi:    model.JenisBiaya,
+			Nominal:           tertagih,
+			TransaksiPemicuID: &tarikID,
+			TagihanBiayaID:    &tagihanID,
+			Rekening:          rekening,
+		})
+		if tertagih == nominalBiaya {
+			status = model.StatusTagihanLunas
+		}
+	}
+
+	repos := siapkanBiaya(rekening, model.Produk{}, model.TagihanBiaya{
+		ID:                tagihanID,
+		RekeningID:        rekening.ID,
+		JenisBiaya:        model.BiayaTarik,
+		TransaksiPemicuID: &tarikID,
+		Nominal:           nominalBiaya,
+		Tertagih:          tertagih,
+		Status:            status,
+	})
+	transaksiRepo := repos.TransaksiRepository.(*fakeTransaksiRepository)
+	transaksiRepo.transaksi = transaksi
+	return &allUsecase{TransaksiRepository: transaksiRepo, UnitOfWork: &fakeUnitOfWork{repos: repos}}, repos
+}
+
+func TestReversalBiaya(t *testing.T) {
+	tests := []struct {
+		nama            string
+		tertagih        model.Money
+		koreksiID       int
+		harapBaris      int
+		harapSaldo      model.Money
+		harapPendapatan model.Money
+		harapSuspense   model.Money
+	}{
+		{"koreksi tarik ikut mengembalikan biayanya", model.Rupiah(5_000), tarikUjiID, 2, model.Rupiah(1_000_000), -model.Rupiah(5_000), -model.Rupiah(100_000)},
+		{"koreksi biaya langsung", model.Rupiah(5_000), biayaUjiID, 1, model.Rupiah(900_000), -model.Rupiah(5_000), 0},
+		{"koreksi tarik membatalkan tunggakan biayanya", 0, tarikUjiID, 1, model.Rupiah(1_000_000), 0, -model.Rupiah(100_000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			u, repos := siapkanKoreksiBiaya(tt.tertagih)
+
+			koreksi, err := u.Reversal(tt.koreksiID, model.Reversal{KodeAlasan: model.AlasanPermintaanNasabah}, "operator-uji")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(koreksi) != tt.harapBaris {
+				t.Fatalf("%d baris koreksi, seharusnya %d", len(koreksi), tt.harapBaris)
+			}
+			for _, k := range koreksi {
+				if k.JenisTransaksi != model.JenisKoreksiKredit || k.TransaksiAsalID == nil {
+					t.Fatalf("baris koreksi %+v tidak mengkredit balik transaksi asalnya", k)
+				}
+			}
+
+			rekening, _ := repos.RekeningRepository.FindByNoREKForUpdate("1000000002")
+			if rekening.Saldo != tt.harapSaldo {
+				t.Fatalf("saldo %d, seharusnya %d", rekening.Saldo, tt.harapSaldo)
+			}
+			bukuBesar := repos.BukuBesarRepository.(*fakeBukuBesarRepository)
+			pendapatan := bukuBesar.saldo[bukuBesar.akun[model.AkunPendapatanBiaya]]
+			suspense := bukuBesar.saldo[bukuBesar.akun[model.AkunSuspense]]
+			if pendapatan != tt.harapPendapatan || suspense != tt.harapSuspense {
+				t.Fatalf("pendapatan biaya %d suspense %d, seharusnya %d %d", pendapatan, suspense, tt.harapPendapatan, tt.harapSuspense)
+			}
+
+			tagihan, _ := repos.BiayaRepository.FindTagihanByID(tagihanUjiID)
+			if tagihan.Status != model.StatusTagihanDibatalkan || tagihan.Tertagih != 0 {
+				t.Fatalf("tagihan berstatus %s tertagih %d, seharusnya dibatalkan tanpa tertagih", tagihan.Status, tagihan.Tertagih)
+			}
+		})
+	}
+}
+
+const (
+	bungaUjiID = 1
+	pajakUjiID = 2
+)
+
+// siapkanKoreksiBunga menyusun rekening yang sudah dikreditkan bunga Rp10.000 dengan pajak
+// Rp2.000 yang menunjuk ke bunga tersebut
+func siapkanKoreksiBunga() (*allUsecase, repository.Repositories, *fakeBungaRepository) {
+	rekening := model.Rekening{
+		ID:         rekeningBiayaID,
+		NasabahID:  5,
+		NoRekening: "1000000002",
+		Saldo:      model.Rupiah(1_008_000),
+		Status:     model.StatusAktif,
+	}
+	bungaID := bungaUjiID
+	repos := siapkanBiaya(rekening, model.Produk{})
+	bukuBesar := repos.BukuBesarRepository.(*fakeBukuBesarRepository)
+	bukuBesar.akun[model.AkunBebanBunga] = 10
+	bukuBesar.akun[model.AkunUtangPajak] = 11
+	bungaRepo := &fakeBungaRepository{dikreditkan: map[int]*int{rekening.ID: &bungaID}}
+	repos.BungaRepository = bungaRepo
+
+	transaksiRepo := repos.TransaksiRepository.(*fakeTransaksiRepository)
+	transaksiRepo.transaksi = []model.Transaksi{
+		{ID: bungaID, RekeningID: rekening.ID, JenisTransaksi: model.JenisBunga, Nominal: model.Rupiah(10_000), Rekening: rekening},
+		{ID: pajakUjiID, RekeningID: rekening.ID, JenisTransaksi: model.JenisPajak, Nominal: model.Rupiah(2_000), TransaksiPemicuID: &bungaID, Rekening: rekening},
+	}
+	return &allUsecase{TransaksiRepository: transaksiRepo, UnitOfWork: &fakeUnitOfWork{repos: repos}}, repos, bungaRepo
+}
+
+func TestReversalBunga(t *testing.T) {
+	tests := []struct {
+		nama             string
+		koreksiID        int
+		harapBaris       int
+		harapSaldo       model.Money
+		harapBebanBunga  model.Money
+		harapUtangPajak  model.Money
+		harapDibukaUlang bool
+	}{
+		{"koreksi bunga ikut mengoreksi pajaknya", bungaUjiID, 2, model.Rupiah(1_000_000), model.Rupiah(10_000), -model.Rupiah(2_000), true},
+		{"koreksi pajak saja", pajakUjiID, 1, model.Rupiah(1_010_000), 0, -model.Rupiah(2_000), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.nama, func(t *testing.T) {
+			u, repos, bungaRepo := siapkanKoreksiBunga()
+
+			koreksi, err := u.Reversal(tt.koreksiID, model.Reversal{KodeAlasan: model.AlasanPermintaanNasabah}, "operator-uji")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(koreksi) != tt.harapBaris {
+				t.Fatalf("%d baris koreksi, seharusnya %d", len(koreksi), tt.harapBaris)
+			}
+
+			rekening, _ := repos.RekeningRepository.FindByNoREKForUpdate("1000000002")
+			if rekening.Saldo != tt.harapSaldo {
+				t.Fatalf("saldo %d, seharusnya %d", rekening.Saldo, tt.harapSaldo)
+			}
+			bukuBesar := repos.BukuBesarRepository.(*fakeBukuBesarRepository)
+			bebanBunga := bukuBesar.saldo[bukuBesar.akun[model.AkunBebanBunga]]
+			utangPajak := bukuBesar.saldo[bukuBesar.akun[model.AkunUtangPajak]]
+			suspense := bukuBesar.saldo[bukuBesar.akun[model.AkunSuspense]]
+			if bebanBunga != tt.harapBebanBunga || utangPajak != tt.harapUtangPajak || suspense != 0 {
+				t.Fatalf("beban bunga %d utang pajak %d suspense %d, seharusnya %d %d 0", bebanBunga, utangPajak, suspense, tt.harapBebanBunga, tt.harapUtangPajak)
+			}
+
+			_, masihDikreditkan := bungaRepo.dikreditkan[rekeningBiayaID]
+			if masihDikreditkan == tt.harapDibukaUlang {
+				t.Fatalf("akru bunga masih dikreditkan = %v, seharusnya %v", masihDikreditkan, !tt.harapDibukaUlang)
+			}
+		})
+	}
+}
+
+func TestReversalTransferSebagian(t *testing.T) {
+	asal := model.Rekening{ID: rekeningBiayaID, NoRekening: "1000000002", Saldo: model.Rupiah(900_000), Status: model.StatusAktif}
+	tujuan := model.Rekening{ID: rekeningBiayaID + 1, NoRekening: "1000000003", Saldo: model.Rupiah(100_000), Status: model.StatusAktif}
+	repos := siapkanBiaya(asal, model.Produk{})
+	repos.RekeningRepository.(*fakeRekeningRepository).rekening[tujuan.NoRekening] = tujuan
+
+	keluarID := 1
+	transaksiRepo := repos.TransaksiRepository.(*fakeTransaksiRepository)
+	transaksiRepo.transaksi = []model.Transaksi{
+		{ID: keluarID, RekeningID: asal.ID, JenisTransaksi: model.JenisTransferKeluar, Nominal: model.Rupiah(100_000), NoReferensi: "TRF1", Rekening: asal},
+		{ID: 2, RekeningID: tujuan.ID, JenisTransaksi: model.JenisTransferMasuk, Nominal: model.Rupiah(100_000), NoReferensi: "TRF1", Rekening: tujuan},
+		{ID: 3, RekeningID: asal.ID, JenisTransaksi: model.JenisKoreksiKredit, Nominal: model.Rupiah(100_000), NoReferensi: "REV1", TransaksiAsalID: &keluarID},
+	}
+	u := &allUsecase{TransaksiRepository: transaksiRepo, UnitOfWork: &fakeUnitOfWork{repos: repos}}
+
+	// sisi masuk diminta lebih dulu supaya pengecekan tidak hanya melihat baris pertama
+	for _, id := range []int{2, keluarID} {
+		_, err := u.Reversal(id, model.Reversal{KodeAlasan: model.AlasanPermintaanNasabah}, "operator-uji")
+		if !errors.Is(err, ErrReversalSebagian) {
+			t.Fatalf("koreksi transaksi %d error %v, seharusnya %v", id, err, ErrReversalSebagian)
+		}
+	}
+	if len(transaksiRepo.transaksi) != 3 {
+		t.Fatalf("%d transaksi tercatat, seharusnya tidak ada koreksi baru", len(transaksiRepo.transaksi))
+	}
+}
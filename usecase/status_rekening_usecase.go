@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"fmt"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// UbahStatusRekening memindahkan status rekening sesuai model.CanTransition. Sebelum ditutup,
+// tunggakan biaya rekening dilunasi dari saldonya. Rekening hanya bisa ditutup jika sisa
+// saldonya 0, atau jika PembayaranAkhir diminta sehingga seluruh saldo dicairkan sebagai
+// transaksi tarik terakhir di dalam transaksi database yang sama.
+func (u *allUsecase) UbahStatusRekening(noREK string, ubah model.UbahStatusRekening, operator string) (model.Rekening, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"no_rekening": noREK,
+		"status":      ubah.Status,
+		"operator":    operator,
+		"action":      "UbahStatusRekening",
+		"layer":       "allUsecase",
+	}).Info("menerima permintaan perubahan status rekening")
+
+	if err := validateNoRek(noREK); err != nil {
+		return model.Rekening{}, err
+	}
+	if !model.IsStatusRekening(ubah.Status) {
+		return model.Rekening{}, ErrInvalidStatus
+	}
+
+	var rekening model.Rekening
+	err := u.UnitOfWork.Do(func(repos repository.Repositories) error {
+		var err error
+		rekening, err = repos.RekeningRepository.FindByNoREKForUpdate(noREK)
+		if err != nil {
+			return err
+		}
+		if rekening.ID == 0 {
+			return ErrAccountNotFound
+		}
+
+		statusLama := rekening.Status
+		if !model.CanTransition(statusLama, ubah.Status) {
+			utils.Log.WithFields(logrus.Fields{
+				"no_rekening": noREK,
+				"dari":        statusLama,
+				"ke":          ubah.Status,
+				"action":      "UbahStatusRekening",
+				"layer":       "allUsecase",
+			}).Warn("Perubahan status rekening tidak diizinkan")
+			return ErrStatusTransition
+		}
+
+		if ubah.Status == model.StatusDitutup {
+			if err := tagihTunggakanPenutupan(repos, &rekening); err != nil {
+				return err
+			}
+		}
+		if ubah.Status == model.StatusDitutup && rekening.Saldo != 0 {
+			if !ubah.PembayaranAkhir || rekening.Saldo < 0 {
+				return ErrNonZeroBalance
+			}
+			if err := payoutSaldo(repos, &rekening); err != nil {
+				return err
+			}
+		}
+
+		rekening.Status = ubah.Status
+		if err := repos.RekeningRepository.UpdateStatus(rekening); err != nil {
+			return err
+		}
+		return recordAudit(repos, rekening.NasabahID, model.AuditStatusRekening,
+			fmt.Sprintf("rekening %s: %s -> %s oleh %s, alasan: %s", noREK, statusLama, ubah.Status, operator, ubah.Alasan))
+	})
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"no_rekening": noREK,
+			"status":      ubah.Status,
+			"operator":    operator,
+			"action":      "UbahStatusRekening",
+			"layer":       "allUsecase",
+		}).Error("Gagal mengubah status rekening")
+		return model.Rekening{}, err
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"no_rekening": noREK,
+		"status":      rekening.Status,
+		"operator":    operator,
+		"action":      "UbahStatusRekening",
+		"layer":       "allUsecase",
+	}).Info("Status rekening berhasil diubah")
+	return rekening, nil
+}
+
+// payoutSaldo mencairkan seluruh saldo rekening sebagai transaksi tarik terakhir sebelum
+// rekening ditutup. Aturan produk sengaja tidak diterapkan karena rekening tidak akan dipakai lagi.
+func payoutSaldo(repos repository.Repositories, rekening *model.Rekening) error {
+	saldoAwal := rekening.Saldo
+	rekening.Saldo = 0
+	jurnal, err := postJurnal(repos, "", "pencairan saldo penutupan rekening "+rekening.NoRekening, []entriJurnal{
+		{Rekening: rekening, Debit: saldoAwal},
+		{KodeAkun: model.AkunKas, Kredit: saldoAwal},
+	})
+	if err != nil {
+		return err
+	}
+
+	transaksi, err := repos.TransaksiRepository.Tarik(model.Transaksi{
+		RekeningID:     rekening.ID,
+		JenisTransaksi: model.JenisTarik,
+		Nominal:        saldoAwal,
+		SaldoAwal:      saldoAwal,
+		SaldoAkhir:     rekening.Saldo,
+		JurnalID:       &jurnal.ID,
+	})
+	if err != nil {
+		return err
+	}
+	if err := recordSaldo(repos, *rekening, transaksi); err != nil {
+		return err
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"no_rekening": rekening.NoRekening,
+		"nominal":     saldoAwal,
+		"action":      "payoutSaldo",
+		"layer":       "allUsecase",
+	}).Info("Saldo rekening dicairkan sebelum penutupan")
+	return nil
+}
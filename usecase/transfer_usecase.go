@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+func (u *allUsecase) Transfer(newTransfer model.Transfer) (model.HasilTransaksi, error) {
+	utils.Log.WithFields(logrus.Fields{
+		"no_rekening_asal":   newTransfer.NoRekeningAsal,
+		"no_rekening_tujuan": newTransfer.NoRekeningTujuan,
+		"nominal":            newTransfer.Nominal,
+		"action":             "transfer",
+		"layer":              "allUsecase",
+	}).Info("menerima permintaan transfer")
+
+	if err := validateNoRek(newTransfer.NoRekeningAsal); err != nil {
+		return model.HasilTransaksi{}, err
+	}
+	if err := validateNoRek(newTransfer.NoRekeningTujuan); err != nil {
+		return model.HasilTransaksi{}, err
+	}
+	if newTransfer.NoRekeningAsal == newTransfer.NoRekeningTujuan {
+		utils.Log.WithFields(logrus.Fields{
+			"no_rekening": newTransfer.NoRekeningAsal,
+			"action":      "validasi rekening transfer",
+			"layer":       "allUsecase",
+		}).Warn("Rekening asal dan tujuan sama")
+		return model.HasilTransaksi{}, ErrSameAccount
+	}
+	if !newTransfer.Nominal.IsWhole() {
+		utils.Log.WithFields(logrus.Fields{
+			"nominal": newTransfer.Nominal,
+			"action":  "validasi nominal bulat",
+			"layer":   "allUsecase",
+		}).Warn("Nominal tidak boleh desimal")
+		return model.HasilTransaksi{}, ErrNominalNotWhole
+	}
+	if newTransfer.Nominal <= 0 {
+		utils.Log.WithFields(logrus.Fields{
+			"nominal": newTransfer.Nominal,
+			"action":  "validasi nominal transfer",
+			"layer":   "allUsecase",
+		}).Warn("Nominal harus lebih dari 0")
+		return model.HasilTransaksi{}, ErrNominalNotPositive
+	}
+
+	pemilik, err := u.RekeningRepository.FindByNoREK(newTransfer.NoRekeningAsal)
+	if err != nil {
+		return model.HasilTransaksi{}, err
+	}
+	if pemilik.ID == 0 {
+		return model.HasilTransaksi{}, ErrAccountNotFound
+	}
+	if err := u.verifyPin(pemilik.NasabahID, newTransfer.Pin); err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"no_rekening": newTransfer.NoRekeningAsal,
+			"action":      "verifikasi pin",
+			"layer":       "allUsecase",
+		}).Warn("Verifikasi PIN transfer gagal")
+		return model.HasilTransaksi{}, err
+	}
+
+	noReferensi, err := utils.GenerateNoReferensi("TRF")
+	if err != nil {
+		return model.HasilTransaksi{}, err
+	}
+
+	var (
+		transaksiKeluar model.Transaksi
+		biaya, saldo    model.Money
+	)
+	err = u.UnitOfWork.Do(func(repos repository.Repositories) error {
+		asal, tujuan, err := lockRekeningPair(repos, newTransfer.NoRekeningAsal, newTransfer.NoRekeningTujuan)
+		if err != nil {
+			return err
+		}
+		if asal.ID == 0 {
+			utils.Log.WithFields(logrus.Fields{
+				"no_rekening": newTransfer.NoRekeningAsal,
+				"action":      "FindByNoREKForUpdate",
+				"layer":       "allUsecase",
+			}).Warn("Rekening asal tidak ditemukan")
+			return ErrAccountNotFound
+		}
+		if tujuan.ID == 0 {
+			utils.Log.WithFields(logrus.Fields{
+				"no_rekening": newTransfer.NoRekeningTujuan,
+				"action":      "FindByNoREKForUpdate",
+				"layer":       "allUsecase",
+			}).Warn("Rekening tujuan tidak ditemukan")
+			return ErrDestinationNotFound
+		}
+
+		sudah, err := transaksiIdempoten(repos, newTransfer.IdempotencyKeyID)
+		if err != nil {
+			return err
+		}
+		if sudah.ID != 0 {
+			transaksiKeluar, saldo = sudah, asal.Saldo
+			biaya, err = biayaDipicu(repos, sudah.ID)
+			return err
+		}
+
+		if err := checkDebit(repos, asal, newTransfer.Nominal, u.Clock.Now()); err != nil {
+			return err
+		}
+		if err := checkCredit(tujuan); err != nil {
+			return err
+		}
+
+		saldoAwalAsal, saldoAwalTujuan := asal.Saldo, tujuan.Saldo
+		asal.Saldo -= newTransfer.Nominal
+		tujuan.Saldo += newTransfer.Nominal
+		jurnal, err := postJurnal(repos, noReferensi, "transfer "+asal.NoRekening+" ke "+tujuan.NoRekening, []entriJurnal{
+			{Rekening: &asal, Debit: newTransfer.Nominal},
+			{Rekening: &tujuan, Kredit: newTransfer.Nominal},
+		})
+		if err != nil {
+			utils.Log.WithFields(logrus.Fields{
+				"no_referensi": noReferensi,
+				"error":        err,
+				"action":       "postJurnal",
+				"layer":        "allUsecase",
+			}).Error("Gagal membukukan jurnal transfer")
+			return err
+		}
+
+		transaksiKeluar, err = repos.TransaksiRepository.Create(model.Transaksi{
+			RekeningID:       asal.ID,
+			JenisTransaksi:   model.JenisTransferKeluar,
+			Nominal:          newTransfer.Nominal,
+			SaldoAwal:        saldoAwalAsal,
+			SaldoAkhir:       asal.Saldo,
+			NoReferensi:      noReferensi,
+			JurnalID:         &jurnal.ID,
+			IdempotencyKeyID: newTransfer.IdempotencyKeyID,
+		})
+		if err != nil {
+			return err
+		}
+		transaksiMasuk, err := repos.TransaksiRepository.Create(model.Transaksi{
+			RekeningID:     tujuan.ID,
+			JenisTransaksi: model.JenisTransferMasuk,
+			Nominal:        newTransfer.Nominal,
+			SaldoAwal:      saldoAwalTujuan,
+			SaldoAkhir:     tujuan.Saldo,
+			NoReferensi:    noReferensi,
+			JurnalID:       &jurnal.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := recordSaldo(repos, asal, transaksiKeluar); err != nil {
+			return err
+		}
+		if err := recordSaldo(repos, tujuan, transaksiMasuk); err != nil {
+			return err
+		}
+		if biaya, err = biayaTransfer(repos, &asal, transaksiKeluar); err != nil {
+			return err
+		}
+		saldo = asal.Saldo
+		return nil
+	})
+	if err != nil {
+		utils.Log.WithError(err).WithFields(logrus.Fields{
+			"no_rekening_asal":   newTransfer.NoRekeningAsal,
+			"no_rekening_tujuan": newTransfer.NoRekeningTujuan,
+			"nominal":            newTransfer.Nominal,
+			"action":             "transfer",
+			"layer":              "allUsecase",
+		}).Error("Transfer gagal, seluruh perubahan di-rollback")
+		return model.HasilTransaksi{}, err
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"no_referensi": transaksiKeluar.NoReferensi,
+		"nominal":      newTransfer.Nominal,
+		"action":       "transfer",
+		"layer":        "allUsecase",
+	}).Info("Transfer berhasil")
+	return model.HasilTransaksi{Transaksi: transaksiKeluar, Biaya: biaya, Saldo: saldo}, nil
+}
+
+// lockRekeningPair mengunci dua rekening selalu dalam urutan no_rekening yang sama
+// sehingga dua transfer berlawanan arah tidak saling deadlock.
+func lockRekeningPair(repos repository.Repositories, noAsal, noTujuan string) (model.Rekening, model.Rekening, error) {
+	first, second := noAsal, noTujuan
+	if second < first {
+		first, second = second, first
+	}
+
+	locked := make(map[string]model.Rekening, 2)
+	for _, noREK := range []string{first, second} {
+		rekening, err := repos.RekeningRepository.FindByNoREKForUpdate(noREK)
+		if err != nil {
+			return model.Rekening{}, model.Rekening{}, err
+		}
+		locked[noREK] = rekening
+	}
+	return locked[noAsal], locked[noTujuan], nil
+}
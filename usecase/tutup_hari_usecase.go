@@ -0,0 +1,211 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/sferawann/go-bank-api/model"
+	"github.com/sferawann/go-bank-api/repository"
+	"github.com/sferawann/go-bank-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const tutupHariBatch = 500
+
+// TutupHariUsecase menjalankan proses akhir hari: mengunci tanggal buku, menyimpan saldo
+// penutupan setiap rekening dan total transaksi per jenis.
+type TutupHariUsecase interface {
+	Jalankan(tanggal time.Time, pemicu string) (model.TutupHari, error)
+	TanggalBerikutnya() (time.Time, error)
+	FindByTanggal(tanggal time.Time) (model.TutupHari, error)
+}
+
+type tutupHariUsecase struct {
+	RekeningRepository  repository.RekeningRepository
+	TransaksiRepository repository.TransaksiRepository
+	TutupHariRepository repository.TutupHariRepository
+	UnitOfWork          repository.UnitOfWork
+	Clock               utils.Clock
+}
+
+// Jalankan menutup satu tanggal buku. Tanggal yang sudah selesai dikembalikan apa adanya,
+// sedangkan tanggal yang terhenti di tengah jalan dilanjutkan dari rekening terakhir yang
+// sudah punya snapshot.
+func (u *tutupHariUsecase) Jalankan(tanggal time.Time, pemicu string) (model.TutupHari, error) {
+	tanggal = awalHari(tanggal)
+	fields := logrus.Fields{
+		"tanggal": tanggal.Format(formatTanggal),
+		"pemicu":  pemicu,
+		"action":  "TutupHari",
+		"layer":   "tutupHariUsecase",
+	}
+
+	if !tanggal.Before(awalHari(u.Clock.Now())) {
+		utils.Log.WithFields(fields).Warn("Tanggal buku belum berakhir")
+		return model.TutupHari{}, ErrTutupHariBelumBerakhir
+	}
+
+	tutupHari, err := u.TutupHariRepository.FindByTanggal(tanggal)
+	if err != nil {
+		return model.TutupHari{}, err
+	}
+	if tutupHari.Status == model.StatusTutupHariSelesai {
+		utils.Log.WithFields(fields).Info("Tanggal buku sudah ditutup sebelumnya")
+		return tutupHari, nil
+	}
+
+	if tutupHari.Status == "" {
+		berikutnya, err := u.TanggalBerikutnya()
+		if err != nil {
+			return model.TutupHari{}, err
+		}
+		if !tanggal.Equal(berikutnya) {
+			utils.Log.WithFields(fields).WithField("tanggal_berikutnya", berikutnya.Format(formatTanggal)).Warn("Tutup hari tidak berurutan")
+			return model.TutupHari{}, ErrTutupHariTidakBerurutan
+		}
+
+		// baris ini langsung di-commit: sejak saat ini transaksi baru tidak bisa lagi
+		// tercatat pada tanggal tersebut
+		err = u.TutupHariRepository.Create(model.TutupHari{
+			Tanggal: tanggal,
+			Status:  model.StatusTutupHariBerjalan,
+			Pemicu:  pemicu,
+			MulaiAt: u.Clock.Now(),
+		})
+		if err != nil {
+			return model.TutupHari{}, err
+		}
+		utils.Log.WithFields(fields).Info("Tanggal buku dikunci, memulai snapshot saldo")
+	} else {
+		utils.Log.WithFields(fields).Info("Melanjutkan tutup hari yang belum selesai")
+	}
+
+	sampai := tanggal.AddDate(0, 0, 1)
+	lastID, err := u.TutupHariRepository.MaxRekeningID(tanggal)
+	if err != nil {
+		return model.TutupHari{}, err
+	}
+	for {
+		rekenings, err := u.RekeningRepository.FindDibukaSebelum(sampai, lastID, tutupHariBatch)
+		if err != nil {
+			return model.TutupHari{}, err
+		}
+		for _, rekening := range rekenings {
+			if err := u.snapshotSaldo(tanggal, rekening.NoRekening); err != nil {
+				utils.Log.WithError(err).WithFields(fields).WithField("no_rekening", rekening.NoRekening).Error("Tutup hari terhenti, jalankan ulang untuk melanjutkan")
+				return model.TutupHari{}, err
+			}
+			lastID = rekening.ID
+		}
+		if len(rekenings) < tutupHariBatch {
+			break
+		}
+	}
+
+	err = u.UnitOfWork.Do(func(repos repository.Repositories) error {
+		total, err := repos.TransaksiRepository.TotalPerJenis(tanggal, sampai)
+		if err != nil {
+			return err
+		}
+		for i := range total {
+			total[i].Tanggal = tanggal
+		}
+		if err := repos.TutupHariRepository.SimpanTotal(total); err != nil {
+			return err
+		}
+
+		jumlah, err := repos.TutupHariRepository.CountSaldoHarian(tanggal)
+		if err != nil {
+			return err
+		}
+		selesai := u.Clock.Now()
+		return repos.TutupHariRepository.Selesai(model.TutupHari{
+			Tanggal:        tanggal,
+			JumlahRekening: jumlah,
+			SelesaiAt:      &selesai,
+		})
+	})
+	if err != nil {
+		return model.TutupHari{}, err
+	}
+
+	tutupHari, err = u.TutupHariRepository.FindByTanggal(tanggal)
+	if err != nil {
+		return model.TutupHari{}, err
+	}
+	utils.Log.WithFields(fields).WithField("jumlah_rekening", tutupHari.JumlahRekening).Info("Tutup hari selesai")
+	return tutupHari, nil
+}
+
+// snapshotSaldo menyimpan saldo penutupan satu rekening. Rekening dikunci lebih dulu agar
+// transaksi pada tanggal tersebut yang masih berjalan sempat di-commit sebelum saldo dihitung.
+func (u *tutupHariUsecase) snapshotSaldo(tanggal time.Time, noREK string) error {
+	return u.UnitOfWork.Do(func(repos repository.Repositories) error {
+		rekening, err := repos.RekeningRepository.FindByNoREKForUpdate(noREK)
+		if err != nil {
+			return err
+		}
+
+		saldo, err := repos.TransaksiRepository.SaldoHarian(rekening.ID, tanggal, tanggal.AddDate(0, 0, 1))
+		if err != nil {
+			return err
+		}
+		saldo.Tanggal = tanggal
+		saldo.RekeningID = rekening.ID
+		saldo.NoRekening = rekening.NoRekening
+		saldo.KodeProduk = rekening.KodeProduk
+		return repos.TutupHariRepository.CreateSaldoHarian(saldo)
+	})
+}
+
+// TanggalBerikutnya mengembalikan tanggal buku yang harus ditutup berikutnya: tanggal yang
+// masih berjalan, hari setelah tanggal terakhir yang selesai, atau tanggal transaksi pertama
+// jika belum pernah ada tutup hari sama sekali, sehingga tidak ada tanggal bertransaksi yang
+// terlewat. Tanpa transaksi sama sekali, yang ditutup pertama adalah kemarin.
+func (u *tutupHariUsecase) TanggalBerikutnya() (time.Time, error) {
+	terakhir, err := u.TutupHariRepository.FindTerakhir()
+	if err != nil {
+		return time.Time{}, err
+	}
+	switch terakhir.Status {
+	case "":
+		pertama, err := u.TransaksiRepository.WaktuTransaksiPertama()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if pertama.IsZero() {
+			return awalHari(u.Clock.Now()).AddDate(0, 0, -1), nil
+		}
+		return awalHari(pertama), nil
+	case model.StatusTutupHariBerjalan:
+		return awalHari(terakhir.Tanggal), nil
+	default:
+		return awalHari(terakhir.Tanggal).AddDate(0, 0, 1), nil
+	}
+}
+
+func (u *tutupHariUsecase) FindByTanggal(tanggal time.Time) (model.TutupHari, error) {
+	tutupHari, err := u.TutupHariRepository.FindByTanggal(awalHari(tanggal))
+	if err != nil {
+		return model.TutupHari{}, err
+	}
+	if tutupHari.Status == "" {
+		return model.TutupHari{}, ErrTutupHariNotFound
+	}
+	return tutupHari, nil
+}
+
+// awalHari mengubah waktu menjadi pukul 00:00 waktu lokal pada tanggal kalendernya.
+// Kolom DATE dari database terbaca sebagai UTC, jadi yang diambil hanya tahun, bulan dan hari.
+func awalHari(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+func NewTutupHariUsecase(rekeningRepository repository.RekeningRepository, transaksiRepository repository.TransaksiRepository, tutupHariRepository repository.TutupHariRepository, unitOfWork repository.UnitOfWork, clock utils.Clock) TutupHariUsecase {
+	return &tutupHariUsecase{
+		RekeningRepository:  rekeningRepository,
+		TransaksiRepository: transaksiRepository,
+		TutupHariRepository: tutupHariRepository,
+		UnitOfWork:          unitOfWork,
+		Clock:               clock,
+	}
+}
@@ -0,0 +1,20 @@
+package utils
+
+import "time"
+
+// Clock adalah sumber waktu yang bisa diganti, supaya proses yang bergantung pada tanggal
+// hari ini (seperti bunga) bisa dijalankan ulang dan diuji dengan waktu yang tetap
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewSystemClock mengembalikan Clock yang membaca jam sistem
+func NewSystemClock() Clock {
+	return systemClock{}
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+const NIKLength = 16
+
+var (
+	ErrNIKFormat       = errors.New("nik harus 16 digit angka")
+	ErrNIKProvinsi     = errors.New("kode provinsi pada nik tidak dikenal")
+	ErrNIKWilayah      = errors.New("kode kabupaten/kota atau kecamatan pada nik tidak valid")
+	ErrNIKTanggalLahir = errors.New("tanggal lahir pada nik tidak valid")
+	ErrNIKNomorUrut    = errors.New("nomor urut pada nik tidak valid")
+)
+
+// kodeProvinsi adalah dua digit pertama NIK sesuai kode wilayah Kemendagri
+var kodeProvinsi = map[string]bool{
+	"11": true, "12": true, "13": true, "14": true, "15": true, "16": true, "17": true, "18": true, "19": true,
+	"21": true,
+	"31": true, "32": true, "33": true, "34": true, "35": true, "36": true,
+	"51": true, "52": true, "53": true,
+	"61": true, "62": true, "63": true, "64": true, "65": true,
+	"71": true, "72": true, "73": true, "74": true, "75": true, "76": true,
+	"81": true, "82": true,
+	"91": true, "92": true, "93": true, "94": true, "95": true, "96": true,
+}
+
+// NIK adalah hasil penguraian Nomor Induk Kependudukan:
+// PPKKCC DDMMYY NNNN (provinsi, kabupaten/kota, kecamatan, tanggal lahir, nomor urut).
+// Untuk perempuan, tanggal lahir ditambah 40.
+type NIK struct {
+	KodeProvinsi  string
+	KodeKabupaten string
+	KodeKecamatan string
+	TanggalLahir  time.Time
+	Perempuan     bool
+}
+
+// ParseNIK memvalidasi dan mengurai NIK. now dipakai untuk menentukan abad tahun lahir:
+// tahun dua digit yang jatuh setelah tahun berjalan dianggap 19xx.
+func ParseNIK(nik string, now time.Time) (NIK, error) {
+	if len(nik) != NIKLength || !allDigits(nik) {
+		return NIK{}, ErrNIKFormat
+	}
+
+	parsed := NIK{
+		KodeProvinsi:  nik[0:2],
+		KodeKabupaten: nik[2:4],
+		KodeKecamatan: nik[4:6],
+	}
+	if !kodeProvinsi[parsed.KodeProvinsi] {
+		return NIK{}, ErrNIKProvinsi
+	}
+	if parsed.KodeKabupaten == "00" || parsed.KodeKecamatan == "00" {
+		return NIK{}, ErrNIKWilayah
+	}
+	if nik[12:16] == "0000" {
+		return NIK{}, ErrNIKNomorUrut
+	}
+
+	day, _ := strconv.Atoi(nik[6:8])
+	month, _ := strconv.Atoi(nik[8:10])
+	year, _ := strconv.Atoi(nik[10:12])
+	if day > 40 {
+		parsed.Perempuan = true
+		day -= 40
+	}
+	year += 2000
+	if year > now.Year() {
+		year -= 100
+	}
+
+	lahir := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	// time.Date menormalkan tanggal seperti 31 Februari, jadi hasilnya dibandingkan ulang
+	if day == 0 || lahir.Day() != day || int(lahir.Month()) != month || lahir.After(now) {
+		return NIK{}, ErrNIKTanggalLahir
+	}
+	parsed.TanggalLahir = lahir
+	return parsed, nil
+}
+
+// Umur menghitung umur dalam tahun penuh pada waktu now
+func (n NIK) Umur(now time.Time) int {
+	umur := now.Year() - n.TanggalLahir.Year()
+	if now.Month() < n.TanggalLahir.Month() ||
+		(now.Month() == n.TanggalLahir.Month() && now.Day() < n.TanggalLahir.Day()) {
+		umur--
+	}
+	return umur
+}
+
+func allDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseNIK(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 10, 0, 0, 0, time.UTC)
+	tanggal := func(tahun int, bulan time.Month, hari int) time.Time {
+		return time.Date(tahun, bulan, hari, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name          string
+		nik           string
+		wantErr       error
+		wantProvinsi  string
+		wantLahir     time.Time
+		wantPerempuan bool
+	}{
+		{name: "laki-laki abad 19xx", nik: "3273011208900001", wantProvinsi: "32", wantLahir: tanggal(1990, time.August, 12)},
+		{name: "perempuan tanggal ditambah 40", nik: "3273015208900001", wantProvinsi: "32", wantLahir: tanggal(1990, time.August, 12), wantPerempuan: true},
+		{name: "perempuan tanggal 31", nik: "3174027112950002", wantProvinsi: "31", wantLahir: tanggal(1995, time.December, 31), wantPerempuan: true},
+		{name: "tahun sebelum tahun berjalan dianggap 20xx", nik: "1101010101050001", wantProvinsi: "11", wantLahir: tanggal(2005, time.January, 1)},
+		{name: "tahun berjalan masih 20xx", nik: "9601011503260001", wantProvinsi: "96", wantLahir: tanggal(2026, time.March, 15)},
+		{name: "tahun setelah tahun berjalan dianggap 19xx", nik: "3578010101270001", wantProvinsi: "35", wantLahir: tanggal(1927, time.January, 1)},
+		{name: "29 februari tahun kabisat", nik: "5171012902000001", wantProvinsi: "51", wantLahir: tanggal(2000, time.February, 29)},
+		{name: "lahir setelah hari ini", nik: "3273011603260001", wantErr: ErrNIKTanggalLahir},
+		{name: "29 februari bukan tahun kabisat", nik: "3273012902010001", wantErr: ErrNIKTanggalLahir},
+		{name: "tanggal nol", nik: "3273010008900001", wantErr: ErrNIKTanggalLahir},
+		{name: "tanggal perempuan lebih dari 71", nik: "3273017208900001", wantErr: ErrNIKTanggalLahir},
+		{name: "bulan 13", nik: "3273011213900001", wantErr: ErrNIKTanggalLahir},
+		{name: "kode provinsi tidak dikenal", nik: "2073011208900001", wantErr: ErrNIKProvinsi},
+		{name: "kode provinsi 00", nik: "0073011208900001", wantErr: ErrNIKProvinsi},
+		{name: "kode kabupaten 00", nik: "3200011208900001", wantErr: ErrNIKWilayah},
+		{name: "kode kecamatan 00", nik: "3273001208900001", wantErr: ErrNIKWilayah},
+		{name: "nomor urut 0000", nik: "3273011208900000", wantErr: ErrNIKNomorUrut},
+		{name: "15 digit", nik: "327301120890000", wantErr: ErrNIKFormat},
+		{name: "17 digit", nik: "32730112089000011", wantErr: ErrNIKFormat},
+		{name: "bukan angka", nik: "32730112089O0001", wantErr: ErrNIKFormat},
+		{name: "kosong", nik: "", wantErr: ErrNIKFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseNIK(tt.nik, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseNIK(%q) error %v, seharusnya %v", tt.nik, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.KodeProvinsi != tt.wantProvinsi || !got.TanggalLahir.Equal(tt.wantLahir) || got.Perempuan != tt.wantPerempuan {
+				t.Fatalf("ParseNIK(%q) = provinsi %s lahir %s perempuan %v, seharusnya %s %s %v",
+					tt.nik, got.KodeProvinsi, got.TanggalLahir.Format("2006-01-02"), got.Perempuan,
+					tt.wantProvinsi, tt.wantLahir.Format("2006-01-02"), tt.wantPerempuan)
+			}
+		})
+	}
+}
+
+func TestNIKUmur(t *testing.T) {
+	nik := NIK{TanggalLahir: time.Date(2008, time.March, 15, 0, 0, 0, 0, time.UTC)}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want int
+	}{
+		{name: "sehari sebelum ulang tahun", now: time.Date(2026, time.March, 14, 23, 59, 59, 0, time.UTC), want: 17},
+		{name: "tepat ulang tahun", now: time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC), want: 18},
+		{name: "bulan sebelum ulang tahun", now: time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC), want: 17},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nik.Umur(tt.now); got != tt.want {
+				t.Fatalf("Umur(%s) = %d, seharusnya %d", tt.now.Format("2006-01-02"), got, tt.want)
+			}
+		})
+	}
+}
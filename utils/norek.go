@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+const (
+	kodeCabangLength = 3
+	nomorUrutLength  = 9
+	// NoRekLength adalah panjang nomor rekening: kode cabang + nomor urut + 1 check digit
+	NoRekLength = kodeCabangLength + nomorUrutLength + 1
+	// LegacyNoRekLength adalah panjang nomor rekening lama: 10 digit acak tanpa check digit.
+	// Rekening yang sudah terbit dengan format ini tetap berlaku.
+	LegacyNoRekLength = 10
+)
+
+// NoRekGenerator membuat nomor rekening berformat <kode cabang><nomor acak><check digit Luhn>
+type NoRekGenerator struct {
+	kodeCabang string
+}
+
+func (g *NoRekGenerator) Generate() (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(nomorUrutLength), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	body := fmt.Sprintf("%s%0*d", g.kodeCabang, nomorUrutLength, n)
+	return body + string(luhnCheckDigit(body)), nil
+}
+
+// ValidNoRek memeriksa panjang, karakter dan check digit nomor rekening tanpa akses database.
+// Nomor format lama (LegacyNoRekLength digit) diterima tanpa check digit.
+func ValidNoRek(noREK string) bool {
+	if len(noREK) != NoRekLength && len(noREK) != LegacyNoRekLength {
+		return false
+	}
+	for _, c := range noREK {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	if len(noREK) == LegacyNoRekLength {
+		return true
+	}
+	body := noREK[:NoRekLength-1]
+	return luhnCheckDigit(body) == noREK[NoRekLength-1]
+}
+
+// luhnCheckDigit menghitung check digit Luhn (mod 10) untuk string angka
+func luhnCheckDigit(digits string) byte {
+	sum := 0
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+func NewNoRekGenerator(kodeCabang string) (*NoRekGenerator, error) {
+	if len(kodeCabang) != kodeCabangLength {
+		return nil, fmt.Errorf("kode cabang harus %d digit", kodeCabangLength)
+	}
+	for _, c := range kodeCabang {
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("kode cabang harus %d digit", kodeCabangLength)
+		}
+	}
+	return &NoRekGenerator{kodeCabang: kodeCabang}, nil
+}
@@ -0,0 +1,35 @@
+package utils
+
+import "testing"
+
+func TestValidNoRek(t *testing.T) {
+	g, err := NewNoRekGenerator("001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	baru, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	salahCheckDigit := baru[:NoRekLength-1] + string('0'+(baru[NoRekLength-1]-'0'+1)%10)
+
+	tests := []struct {
+		name  string
+		noREK string
+		want  bool
+	}{
+		{name: "format baru", noREK: baru, want: true},
+		{name: "format baru check digit salah", noREK: salahCheckDigit, want: false},
+		{name: "format lama 10 digit", noREK: "0123456789", want: true},
+		{name: "format lama bukan angka", noREK: "01234567a9", want: false},
+		{name: "11 digit", noREK: "01234567890", want: false},
+		{name: "kosong", noREK: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidNoRek(tt.noREK); got != tt.want {
+				t.Fatalf("ValidNoRek(%q) = %v, seharusnya %v", tt.noREK, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	kodeNegaraIndonesia = "62"
+	// panjang nomor seluler setelah kode negara, misalnya 812 3456 7890
+	minNoHPDigits = 9
+	maxNoHPDigits = 12
+)
+
+var ErrNoHPFormat = errors.New("no hp harus nomor seluler Indonesia, misalnya 081234567890")
+
+// NormalizeNoHP mengubah nomor seluler Indonesia ke format E.164 (+628...) sehingga
+// 0812..., 62812... dan +62812... dianggap nomor yang sama. Spasi, tanda hubung,
+// titik dan kurung diabaikan.
+func NormalizeNoHP(noHP string) (string, error) {
+	cleaned := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, noHP)
+
+	var nasional string
+	switch {
+	case strings.HasPrefix(cleaned, "+"+kodeNegaraIndonesia):
+		nasional = cleaned[len(kodeNegaraIndonesia)+1:]
+	case strings.HasPrefix(cleaned, kodeNegaraIndonesia):
+		nasional = cleaned[len(kodeNegaraIndonesia):]
+	case strings.HasPrefix(cleaned, "0"):
+		nasional = cleaned[1:]
+	default:
+		return "", ErrNoHPFormat
+	}
+
+	if !strings.HasPrefix(nasional, "8") || !allDigits(nasional) ||
+		len(nasional) < minNoHPDigits || len(nasional) > maxNoHPDigits {
+		return "", ErrNoHPFormat
+	}
+	return "+" + kodeNegaraIndonesia + nasional, nil
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeNoHP(t *testing.T) {
+	tests := []struct {
+		name    string
+		noHP    string
+		want    string
+		wantErr error
+	}{
+		{name: "format E.164", noHP: "+6281234567890", want: "+6281234567890"},
+		{name: "awalan 08", noHP: "081234567890", want: "+6281234567890"},
+		{name: "awalan 62 tanpa plus", noHP: "6281234567890", want: "+6281234567890"},
+		{name: "spasi dan tanda hubung diabaikan", noHP: "0812-3456 7890", want: "+6281234567890"},
+		{name: "kurung dan titik diabaikan", noHP: "+62 (812) 3456.7890", want: "+6281234567890"},
+		{name: "panjang minimum", noHP: "0812345678", want: "+62812345678"},
+		{name: "panjang maksimum", noHP: "0812345678901", want: "+62812345678901"},
+		{name: "terlalu pendek", noHP: "081234567", wantErr: ErrNoHPFormat},
+		{name: "terlalu panjang", noHP: "08123456789012", wantErr: ErrNoHPFormat},
+		{name: "nomor telepon rumah", noHP: "0215551234", wantErr: ErrNoHPFormat},
+		{name: "kode negara lain", noHP: "+6591234567", wantErr: ErrNoHPFormat},
+		{name: "tanpa awalan", noHP: "81234567890", wantErr: ErrNoHPFormat},
+		{name: "mengandung huruf", noHP: "08123456789a", wantErr: ErrNoHPFormat},
+		{name: "plus di tengah", noHP: "0+6281234567", wantErr: ErrNoHPFormat},
+		{name: "kosong", noHP: "", wantErr: ErrNoHPFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeNoHP(tt.noHP)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Fatalf("NormalizeNoHP(%q) = %q, %v, seharusnya %q, %v", tt.noHP, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
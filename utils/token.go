@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidToken = errors.New("token tidak valid")
+
+// TokenManager membuat dan memverifikasi token JWT HS256 berisi ID nasabah
+type TokenManager struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+type tokenClaims struct {
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+func (m *TokenManager) Generate(nasabahID int) (string, time.Time, error) {
+	now := time.Now()
+	expiredAt := now.Add(m.ttl)
+	claims, err := json.Marshal(tokenClaims{
+		Subject:   strconv.Itoa(nasabahID),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiredAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	unsigned := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return unsigned + "." + m.sign(unsigned), expiredAt, nil
+}
+
+// Parse mengembalikan ID nasabah jika tanda tangan token cocok dan token belum kedaluwarsa
+func (m *TokenManager) Parse(token string) (int, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return 0, ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(m.sign(parts[0]+"."+parts[1]))) {
+		return 0, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+	var claims tokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return 0, ErrInvalidToken
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return 0, ErrInvalidToken
+	}
+	nasabahID, err := strconv.Atoi(claims.Subject)
+	if err != nil || nasabahID <= 0 {
+		return 0, ErrInvalidToken
+	}
+	return nasabahID, nil
+}
+
+func (m *TokenManager) sign(unsigned string) string {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func NewTokenManager(secret []byte, ttl time.Duration) *TokenManager {
+	return &TokenManager{secret: secret, ttl: ttl}
+}
@@ -1,11 +1,21 @@
 package utils
 
 import (
-	"fmt"
-	"math/rand"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
 )
 
-func GenerateNoRek() string {
-	randomNumber := rand.Int63n(10000000000)
-	return fmt.Sprintf("%010d", randomNumber)
+// noReferensiAcakBytes adalah jumlah byte acak di nomor referensi (16 digit heksadesimal)
+const noReferensiAcakBytes = 8
+
+// GenerateNoReferensi membuat nomor referensi unik untuk mengaitkan beberapa baris transaksi.
+// Bagian acaknya dari crypto/rand sehingga dua transaksi pada detik yang sama praktis tidak
+// mungkin berbagi nomor, dan unique index transaksi menolak sisanya.
+func GenerateNoReferensi(prefix string) (string, error) {
+	acak := make([]byte, noReferensiAcakBytes)
+	if _, err := rand.Read(acak); err != nil {
+		return "", err
+	}
+	return prefix + time.Now().Format("20060102150405") + hex.EncodeToString(acak), nil
 }
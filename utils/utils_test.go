@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateNoReferensi(t *testing.T) {
+	const jumlah = 10000
+	terpakai := make(map[string]bool, jumlah)
+	for i := 0; i < jumlah; i++ {
+		noReferensi, err := GenerateNoReferensi("TRF")
+		if err != nil {
+			t.Fatal(err)
+		}
+		// kolom no_referensi bertipe VARCHAR(50)
+		if !strings.HasPrefix(noReferensi, "TRF") || len(noReferensi) > 50 {
+			t.Fatalf("format nomor referensi %q tidak valid", noReferensi)
+		}
+		if terpakai[noReferensi] {
+			t.Fatalf("nomor referensi %q terbit dua kali", noReferensi)
+		}
+		terpakai[noReferensi] = true
+	}
+}